@@ -0,0 +1,13 @@
+package analytics
+
+import (
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+type Service struct {
+	db dbase.DBService
+}
+
+func NewService(db dbase.DBService) *Service {
+	return &Service{db: db}
+}
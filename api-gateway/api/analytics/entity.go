@@ -0,0 +1,116 @@
+package analytics
+
+import (
+	"time"
+)
+
+// StationUtilization is the utilization of one station. PctEmpty and
+// PctFull are the percent of snapshots without bike and without free dock,
+// Turnover the average absolute change of bikes between two snapshots
+type StationUtilization struct {
+	Rank      int     `json:"rank"`
+	KioskID   int     `json:"kioskId"`
+	Name      string  `json:"name"`
+	Zip       string  `json:"zip"`
+	KioskType int     `json:"kioskType"`
+	Samples   int     `json:"samples"`
+	PctEmpty  float64 `json:"pctEmpty"`
+	PctFull   float64 `json:"pctFull"`
+	Turnover  float64 `json:"turnover"`
+}
+
+type Utilization struct {
+	From     time.Time            `json:"from"`
+	To       time.Time            `json:"to"`
+	RankBy   string               `json:"rankBy"`
+	Total    int                  `json:"total"`
+	Stations []StationUtilization `json:"stations"`
+}
+
+// WeatherBand is the availability of the snapshots in one weather band.
+// Turnover is the average absolute change of bikes since the previous
+// snapshot of the station
+type WeatherBand struct {
+	Band     string  `json:"band"`
+	Samples  int     `json:"samples"`
+	BikesAvg float64 `json:"bikesAvg"`
+	Turnover float64 `json:"turnover"`
+}
+
+type WeatherImpact struct {
+	KioskID     *int          `json:"kioskId,omitempty"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Temperature []WeatherBand `json:"temperature"`
+	Rain        []WeatherBand `json:"rain"`
+	Wind        []WeatherBand `json:"wind"`
+	Condition   []WeatherBand `json:"condition"`
+}
+
+// FlowInterval is the bikes which left and returned in one hour. Net is
+// arrivals minus departures
+type FlowInterval struct {
+	At         time.Time `json:"at"`
+	Departures int       `json:"departures"`
+	Arrivals   int       `json:"arrivals"`
+	Net        int       `json:"net"`
+}
+
+type StationFlow struct {
+	KioskID    int `json:"kioskId"`
+	Departures int `json:"departures"`
+	Arrivals   int `json:"arrivals"`
+	Net        int `json:"net"`
+}
+
+// FlowHour is the flows of all stations in one hour of the day, in the
+// TimeZone of Flows
+type FlowHour struct {
+	Hour       int `json:"hour"`
+	Departures int `json:"departures"`
+	Arrivals   int `json:"arrivals"`
+}
+
+type Flows struct {
+	KioskID      *int           `json:"kioskId,omitempty"`
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	Departures   int            `json:"departures"`
+	Arrivals     int            `json:"arrivals"`
+	Intervals    []FlowInterval `json:"intervals"`
+	Stations     []StationFlow  `json:"stations"`
+	BusiestHours []FlowHour     `json:"busiestHours"`
+	TimeZone     string         `json:"timeZone"`
+}
+
+// ForecastValue is a predicted count with its 95% confidence band
+type ForecastValue struct {
+	Value float64 `json:"value"`
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+}
+
+// ForecastWeather is the latest stored weather. Adjusted tells the
+// prediction was adjusted by Samples snapshots taken in a similar weather
+type ForecastWeather struct {
+	At          time.Time `json:"at"`
+	Temp        float64   `json:"temp"`
+	RainOneHour float64   `json:"rainOneHour"`
+	Adjusted    bool      `json:"adjusted"`
+	Samples     int       `json:"samples"`
+}
+
+// Forecast is the prediction of one station at At. Weekday and Hour are
+// in TimeZone
+type Forecast struct {
+	KioskID        int              `json:"kioskId"`
+	At             time.Time        `json:"at"`
+	Weekday        string           `json:"weekday"`
+	Hour           int              `json:"hour"`
+	TimeZone       string           `json:"timeZone"`
+	Samples        int              `json:"samples"`
+	LookbackDays   int              `json:"lookbackDays"`
+	BikesAvailable ForecastValue    `json:"bikesAvailable"`
+	DocksAvailable ForecastValue    `json:"docksAvailable"`
+	Weather        *ForecastWeather `json:"weather,omitempty"`
+}
@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+// BusiestHours is the number of hours of the day in the flows summary
+const BusiestHours = 5
+
+// RefreshFlows rebuild the flows of the hours touched by the range from to
+func (s *Service) RefreshFlows(from time.Time, to time.Time) error {
+	return s.db.RefreshStationFlows(context.Background(), from, to)
+}
+
+// Flows returns the flows of the hours starting between q.From and q.To by
+// hour and by station, and the busiest hours of the day of all stations
+func (s *Service) Flows(q dbase.FlowQuery) (*Flows, int, error) {
+	if err := dbase.ValidateAnalyticsRange(q.From, q.To); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	result, err := s.db.SearchStationFlows(context.Background(), q, BusiestHours)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read flows")
+	}
+	if len(result.Intervals) == 0 {
+		return nil, http.StatusNotFound, errors.New("Data Not Found")
+	}
+
+	flows := Flows{
+		KioskID:      q.KioskID,
+		From:         q.From.UTC(),
+		To:           q.To.UTC(),
+		Intervals:    make([]FlowInterval, 0, len(result.Intervals)),
+		Stations:     make([]StationFlow, 0, len(result.Stations)),
+		BusiestHours: make([]FlowHour, 0, len(result.BusiestHours)),
+		TimeZone:     dbase.TimeZone,
+	}
+
+	for _, row := range result.Intervals {
+		flows.Departures += row.Departures
+		flows.Arrivals += row.Arrivals
+		flows.Intervals = append(flows.Intervals, FlowInterval{
+			At:         row.Bucket.UTC(),
+			Departures: row.Departures,
+			Arrivals:   row.Arrivals,
+			Net:        row.Arrivals - row.Departures,
+		})
+	}
+
+	for _, row := range result.Stations {
+		flows.Stations = append(flows.Stations, StationFlow{
+			KioskID:    row.KioskID,
+			Departures: row.Departures,
+			Arrivals:   row.Arrivals,
+			Net:        row.Arrivals - row.Departures,
+		})
+	}
+
+	for _, row := range result.BusiestHours {
+		flows.BusiestHours = append(flows.BusiestHours, FlowHour{
+			Hour:       row.Hour,
+			Departures: row.Departures,
+			Arrivals:   row.Arrivals,
+		})
+	}
+
+	return &flows, http.StatusOK, nil
+}
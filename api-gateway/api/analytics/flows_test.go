@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/arthben/BackendGolang/api-gateway/internal/database/dbtest"
+)
+
+func TestFlows(t *testing.T) {
+	from := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	db := &dbtest.FakeDB{Flows: dbase.SearchResFlows{
+		Intervals: []*dbase.FlowInterval{
+			{Bucket: from, Departures: 5, Arrivals: 2},
+			{Bucket: from.Add(time.Hour), Departures: 1, Arrivals: 6},
+		},
+		Stations: []*dbase.StationFlow{
+			{KioskID: 3005, Departures: 6, Arrivals: 8},
+		},
+		BusiestHours: []*dbase.FlowHour{
+			{Hour: 1, Departures: 1, Arrivals: 6},
+			{Hour: 0, Departures: 5, Arrivals: 2},
+		},
+	}}
+
+	flows, httpCode, _ := NewService(db).Flows(dbase.FlowQuery{From: from, To: from.Add(2 * time.Hour)})
+	if httpCode != http.StatusOK {
+		t.Fatalf("Expected status %d but got response %d", http.StatusOK, httpCode)
+	}
+
+	if flows.Departures != 6 || flows.Arrivals != 8 {
+		t.Errorf("Expected 6 departures and 8 arrivals but got %d and %d", flows.Departures, flows.Arrivals)
+	}
+	if flows.Intervals[0].Net != -3 || flows.Intervals[1].Net != 5 {
+		t.Errorf("Expected net -3 and 5 but got %d and %d", flows.Intervals[0].Net, flows.Intervals[1].Net)
+	}
+	if len(flows.Stations) != 1 || flows.Stations[0].Net != 2 {
+		t.Errorf("Expected one station with net 2 but got %+v", flows.Stations)
+	}
+	if len(flows.BusiestHours) != 2 || flows.BusiestHours[0].Hour != 1 {
+		t.Errorf("Expected hour 1 as the busiest but got %+v", flows.BusiestHours)
+	}
+	if flows.TimeZone != dbase.TimeZone {
+		t.Errorf("Expected the hours in %s but got %s", dbase.TimeZone, flows.TimeZone)
+	}
+
+	_, httpCode, _ = NewService(&dbtest.FakeDB{}).Flows(dbase.FlowQuery{From: from, To: from.Add(time.Hour)})
+	if httpCode != http.StatusNotFound {
+		t.Errorf("Expected status %d but got response %d", http.StatusNotFound, httpCode)
+	}
+
+	_, httpCode, _ = NewService(db).Flows(dbase.FlowQuery{From: from, To: from.Add(-time.Hour)})
+	if httpCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d but got response %d", http.StatusBadRequest, httpCode)
+	}
+}
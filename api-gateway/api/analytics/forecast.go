@@ -0,0 +1,200 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+	// the runtime image has no zoneinfo
+	_ "time/tzdata"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+const (
+	// ForecastLookback is how far back the history of a forecast goes
+	ForecastLookback = 12 * 7 * 24 * time.Hour
+	// ForecastWeatherHorizon is how far ahead the latest weather is used
+	// to adjust a forecast
+	ForecastWeatherHorizon = 24 * time.Hour
+	// MinWeatherSamples is the least samples in a similar weather to
+	// adjust a forecast
+	MinWeatherSamples = 5
+	// similarTemp is the largest difference of temperature, in Kelvin or
+	// Celsius, of a similar weather
+	similarTemp = 5
+	// ForecastTimeZone is the zone of the weekday and hour of a forecast,
+	// the stations are in Philadelphia and their demand follows local time
+	ForecastTimeZone = dbase.TimeZone
+	// z95 is the z-score of a 95% confidence band
+	z95 = 1.96
+)
+
+// Forecast predict bikesAvailable and docksAvailable of one station at a
+// future time. The baseline is the mean of the snapshots taken on the
+// same weekday and hour in ForecastTimeZone over the last
+// ForecastLookback, adjusted by the snapshots taken in a weather similar
+// to the latest stored one
+func (s *Service) Forecast(kioskID int, at time.Time) (*Forecast, int, error) {
+	now := time.Now().UTC()
+	if !at.After(now) {
+		return nil, http.StatusBadRequest, errors.New("at must be in the future")
+	}
+
+	loc, err := time.LoadLocation(ForecastTimeZone)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while load forecast time zone")
+	}
+
+	ctx := context.Background()
+	at = at.UTC()
+	local := at.In(loc)
+	samples, err := s.db.SearchForecastSamples(ctx, dbase.ForecastQuery{
+		KioskID:  kioskID,
+		Weekday:  local.Weekday(),
+		Hour:     local.Hour(),
+		TimeZone: ForecastTimeZone,
+		From:     now.Add(-ForecastLookback),
+		To:       now,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read station history")
+	}
+	if len(samples) == 0 {
+		return nil, http.StatusNotFound, errors.New("Data Not Found")
+	}
+
+	// without weather the forecast is the seasonal baseline only
+	var weather *dbase.OpenWeatherMaster
+	if at.Sub(now) <= ForecastWeatherHorizon {
+		latest, err := s.db.SearchOpenWeather(ctx, dbase.SnapshotQuery{
+			At:     now,
+			Mode:   dbase.ModeBefore,
+			MaxAge: ForecastWeatherHorizon,
+		})
+		if err == nil && latest.Master != nil {
+			weather = latest.Master
+		}
+	}
+
+	forecast := predict(samples, weather)
+	forecast.KioskID = kioskID
+	forecast.At = at
+	forecast.Weekday = local.Weekday().String()
+	forecast.Hour = local.Hour()
+	forecast.TimeZone = ForecastTimeZone
+	forecast.LookbackDays = int(ForecastLookback.Hours() / 24)
+
+	return forecast, http.StatusOK, nil
+}
+
+// predict returns the forecast of samples, adjusted by weather when not nil
+// and there are at least MinWeatherSamples samples in a similar weather
+func predict(samples []*dbase.ForecastSample, weather *dbase.OpenWeatherMaster) *Forecast {
+	bikes, docks := make([]float64, 0, len(samples)), make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		bikes = append(bikes, float64(sample.BikesAvailable))
+		docks = append(docks, float64(sample.DocksAvailable))
+	}
+
+	bikesMean, bikesSD := meanSD(bikes)
+	docksMean, docksSD := meanSD(docks)
+
+	forecast := Forecast{Samples: len(samples)}
+	if weather != nil {
+		forecast.Weather = &ForecastWeather{
+			At:          time.Unix(int64(weather.DT), 0).UTC(),
+			Temp:        round2(weather.MainTemp - 273.15),
+			RainOneHour: weather.RainOneHour,
+		}
+
+		var similarBikes, similarDocks []float64
+		for _, sample := range samples {
+			if similarWeather(sample, weather) {
+				similarBikes = append(similarBikes, float64(sample.BikesAvailable))
+				similarDocks = append(similarDocks, float64(sample.DocksAvailable))
+			}
+		}
+
+		forecast.Weather.Samples = len(similarBikes)
+		if len(similarBikes) >= MinWeatherSamples {
+			forecast.Weather.Adjusted = true
+			bikesMean, _ = meanSD(similarBikes)
+			docksMean, _ = meanSD(similarDocks)
+		}
+	}
+
+	// the band can not go over the docks of the station
+	capacity := float64(samples[len(samples)-1].TotalDocks)
+	forecast.BikesAvailable = band(bikesMean, bikesSD, capacity)
+	forecast.DocksAvailable = band(docksMean, docksSD, capacity)
+
+	return &forecast
+}
+
+// similarWeather tells the sample was taken with rain when weather has rain
+// and without otherwise, and at a temperature near the one of weather
+func similarWeather(sample *dbase.ForecastSample, weather *dbase.OpenWeatherMaster) bool {
+	if sample.MainTemp == nil {
+		return false
+	}
+
+	var rain float64
+	if sample.RainOneHour != nil {
+		rain = *sample.RainOneHour
+	}
+
+	return (rain > 0) == (weather.RainOneHour > 0) &&
+		math.Abs(*sample.MainTemp-weather.MainTemp) <= similarTemp
+}
+
+func band(mean float64, sd float64, capacity float64) ForecastValue {
+	value := ForecastValue{
+		Value: round2(mean),
+		Low:   round2(mean - z95*sd),
+		High:  round2(mean + z95*sd),
+	}
+
+	value.Value = clamp(value.Value, capacity)
+	value.Low = clamp(value.Low, capacity)
+	value.High = clamp(value.High, capacity)
+	return value
+}
+
+func clamp(x float64, capacity float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	if capacity > 0 && x > capacity {
+		return capacity
+	}
+	return x
+}
+
+// meanSD returns the mean and the sample standard deviation of values
+func meanSD(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	if len(values) < 2 {
+		return mean, 0
+	}
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)-1))
+}
+
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
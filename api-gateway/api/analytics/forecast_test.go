@@ -0,0 +1,140 @@
+package analytics
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/arthben/BackendGolang/api-gateway/internal/database/dbtest"
+)
+
+// syntheticHistory returns one sample per week on Monday 08:00 UTC of a
+// station with 20 docks, clear weeks have 10 bikes and rainy weeks 16
+func syntheticHistory(clear int, rainy int) []*dbase.ForecastSample {
+	var (
+		samples []*dbase.ForecastSample
+		at      = time.Date(2024, 8, 5, 8, 0, 0, 0, time.UTC)
+	)
+
+	for i := 0; i < clear+rainy; i++ {
+		temp, rain := 290.0, 0.0
+		sample := &dbase.ForecastSample{At: at, BikesAvailable: 10, DocksAvailable: 10, TotalDocks: 20}
+		if i >= clear {
+			temp, rain = 288.0, 2.0
+			sample.BikesAvailable, sample.DocksAvailable = 16, 4
+		}
+		sample.MainTemp, sample.RainOneHour = &temp, &rain
+
+		samples = append(samples, sample)
+		at = at.AddDate(0, 0, 7)
+	}
+	return samples
+}
+
+func TestPredict(t *testing.T) {
+	rainy := &dbase.OpenWeatherMaster{DT: 1730102400, MainTemp: 287, RainOneHour: 1.5}
+	clear := &dbase.OpenWeatherMaster{DT: 1730102400, MainTemp: 291}
+
+	scenarios := []struct {
+		name             string
+		samples          []*dbase.ForecastSample
+		weather          *dbase.OpenWeatherMaster
+		expectedBikes    float64
+		expectedDocks    float64
+		expectedAdjusted bool
+	}{
+		{
+			name:          "Baseline without weather",
+			samples:       syntheticHistory(8, 6),
+			expectedBikes: 12.57,
+			expectedDocks: 7.43,
+		},
+		{
+			name:             "Adjusted by rain",
+			samples:          syntheticHistory(8, 6),
+			weather:          rainy,
+			expectedBikes:    16,
+			expectedDocks:    4,
+			expectedAdjusted: true,
+		},
+		{
+			name:             "Adjusted by clear weather",
+			samples:          syntheticHistory(8, 6),
+			weather:          clear,
+			expectedBikes:    10,
+			expectedDocks:    10,
+			expectedAdjusted: true,
+		},
+		{
+			name:          "Not adjusted, too few rainy samples",
+			samples:       syntheticHistory(8, MinWeatherSamples-1),
+			weather:       rainy,
+			expectedBikes: 12,
+			expectedDocks: 8,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			forecast := predict(ts.samples, ts.weather)
+
+			if forecast.BikesAvailable.Value != ts.expectedBikes || forecast.DocksAvailable.Value != ts.expectedDocks {
+				t.Errorf("Expected %v bikes and %v docks but got %v and %v",
+					ts.expectedBikes, ts.expectedDocks, forecast.BikesAvailable.Value, forecast.DocksAvailable.Value)
+			}
+			if forecast.Samples != len(ts.samples) {
+				t.Errorf("Expected %d samples but got %d", len(ts.samples), forecast.Samples)
+			}
+			if adjusted := forecast.Weather != nil && forecast.Weather.Adjusted; adjusted != ts.expectedAdjusted {
+				t.Errorf("Expected adjusted %v but got %v", ts.expectedAdjusted, adjusted)
+			}
+
+			// the band holds the value and stay within the docks of the station
+			for _, value := range []ForecastValue{forecast.BikesAvailable, forecast.DocksAvailable} {
+				if value.Low > value.Value || value.High < value.Value || value.Low < 0 || value.High > 20 {
+					t.Errorf("Expected 0 <= low <= value <= high <= 20 but got %+v", value)
+				}
+			}
+		})
+	}
+}
+
+func TestForecast(t *testing.T) {
+	db := &dbtest.FakeDB{
+		ForecastSamples: syntheticHistory(8, 6),
+		Weather:         &dbase.OpenWeatherMaster{DT: int(time.Now().Unix()), MainTemp: 287, RainOneHour: 1.5},
+	}
+	at := time.Now().Add(2 * time.Hour)
+
+	forecast, httpCode, _ := NewService(db).Forecast(3005, at)
+	if httpCode != http.StatusOK {
+		t.Fatalf("Expected status %d but got response %d", http.StatusOK, httpCode)
+	}
+	loc, _ := time.LoadLocation(ForecastTimeZone)
+	local := at.In(loc)
+	if db.ForecastQuery.Weekday != local.Weekday() || db.ForecastQuery.Hour != local.Hour() || db.ForecastQuery.TimeZone != ForecastTimeZone {
+		t.Errorf("Expected samples of %v %d:00 %s but got %v %d:00 %s",
+			local.Weekday(), local.Hour(), ForecastTimeZone, db.ForecastQuery.Weekday, db.ForecastQuery.Hour, db.ForecastQuery.TimeZone)
+	}
+	if db.WeatherQuery.MaxAge != ForecastWeatherHorizon {
+		t.Errorf("Expected weather at most %v old but got %v", ForecastWeatherHorizon, db.WeatherQuery.MaxAge)
+	}
+	if forecast.Weather == nil || !forecast.Weather.Adjusted || forecast.BikesAvailable.Value != 16 {
+		t.Errorf("Expected 16 bikes adjusted by rain but got %+v", forecast.BikesAvailable)
+	}
+
+	// too far ahead for the latest weather
+	forecast, _, _ = NewService(db).Forecast(3005, time.Now().Add(72*time.Hour))
+	if forecast.Weather != nil || forecast.BikesAvailable.Value != 12.57 {
+		t.Errorf("Expected 12.57 bikes without weather but got %+v", forecast.BikesAvailable)
+	}
+
+	if _, httpCode, _ := NewService(db).Forecast(3005, time.Now().Add(-time.Hour)); httpCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d but got response %d", http.StatusBadRequest, httpCode)
+	}
+
+	if _, httpCode, _ := NewService(&dbtest.FakeDB{}).Forecast(3005, at); httpCode != http.StatusNotFound {
+		t.Errorf("Expected status %d but got response %d", http.StatusNotFound, httpCode)
+	}
+}
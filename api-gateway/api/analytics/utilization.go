@@ -0,0 +1,68 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+const (
+	// DefaultUtilizationTop is the number of stations returned without top
+	DefaultUtilizationTop = 20
+	// MaxUtilizationTop is the largest top of one utilization response
+	MaxUtilizationTop = 500
+)
+
+// Utilization returns the utilization of the stations over the snapshots
+// between q.From and q.To, ranked by q.RankBy. Empty rank means empty and
+// zero top means DefaultUtilizationTop
+func (s *Service) Utilization(q dbase.UtilizationQuery) (*Utilization, int, error) {
+	if len(q.RankBy) == 0 {
+		q.RankBy = dbase.RankEmpty
+	}
+	if !dbase.ValidUtilizationRank(q.RankBy) {
+		return nil, http.StatusBadRequest, errors.New("Invalid rankBy, use empty, full or turnover")
+	}
+	if q.Top == 0 {
+		q.Top = DefaultUtilizationTop
+	}
+	if q.Top < 0 || q.Top > MaxUtilizationTop {
+		return nil, http.StatusBadRequest, errors.New("top must be between 1 and 500")
+	}
+	if err := dbase.ValidateAnalyticsRange(q.From, q.To); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	rows, err := s.db.SearchUtilization(context.Background(), q)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read utilization")
+	}
+	if len(rows) == 0 {
+		return nil, http.StatusNotFound, errors.New("Data Not Found")
+	}
+
+	utilization := Utilization{
+		From:     q.From.UTC(),
+		To:       q.To.UTC(),
+		RankBy:   q.RankBy,
+		Total:    rows[0].Total,
+		Stations: make([]StationUtilization, 0, len(rows)),
+	}
+	for _, row := range rows {
+		utilization.Stations = append(utilization.Stations, StationUtilization{
+			Rank:      row.Rank,
+			KioskID:   row.KioskID,
+			Name:      row.Name,
+			Zip:       row.Zip,
+			KioskType: row.KioskType,
+			Samples:   row.Samples,
+			PctEmpty:  row.PctEmpty,
+			PctFull:   row.PctFull,
+			Turnover:  row.Turnover,
+		})
+	}
+
+	return &utilization, http.StatusOK, nil
+}
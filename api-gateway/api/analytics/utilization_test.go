@@ -0,0 +1,91 @@
+package analytics
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/arthben/BackendGolang/api-gateway/internal/database/dbtest"
+)
+
+func TestUtilization(t *testing.T) {
+	from := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	rows := []*dbase.StationUtilization{
+		{Rank: 1, KioskID: 3005, PctEmpty: 40, Total: 2},
+		{Rank: 2, KioskID: 3006, PctEmpty: 10, Total: 2},
+	}
+
+	scenarios := []struct {
+		name           string
+		query          dbase.UtilizationQuery
+		rows           []*dbase.StationUtilization
+		expectedStatus int
+		expectedRankBy string
+		expectedTop    int
+	}{
+		{
+			name:           "Success - Default rank and top",
+			query:          dbase.UtilizationQuery{From: from, To: from.Add(24 * time.Hour)},
+			rows:           rows,
+			expectedStatus: http.StatusOK,
+			expectedRankBy: dbase.RankEmpty,
+			expectedTop:    DefaultUtilizationTop,
+		},
+		{
+			name:           "Success - Turnover",
+			query:          dbase.UtilizationQuery{From: from, To: from.Add(24 * time.Hour), RankBy: dbase.RankTurnover, Top: 5},
+			rows:           rows,
+			expectedStatus: http.StatusOK,
+			expectedRankBy: dbase.RankTurnover,
+			expectedTop:    5,
+		},
+		{
+			name:           "Fail - RankBy not valid",
+			query:          dbase.UtilizationQuery{From: from, To: from.Add(24 * time.Hour), RankBy: "pct_empty"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - Top not valid",
+			query:          dbase.UtilizationQuery{From: from, To: from.Add(24 * time.Hour), Top: MaxUtilizationTop + 1},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - From after to",
+			query:          dbase.UtilizationQuery{From: from, To: from.Add(-time.Hour)},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - Range too long",
+			query:          dbase.UtilizationQuery{From: from, To: from.Add(dbase.MaxAnalyticsRange + time.Hour)},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - No Data Found",
+			query:          dbase.UtilizationQuery{From: from, To: from.Add(24 * time.Hour)},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			db := &dbtest.FakeDB{Utilization: ts.rows}
+
+			utilization, httpCode, _ := NewService(db).Utilization(ts.query)
+			if httpCode != ts.expectedStatus {
+				t.Fatalf("Expected status %d but got response %d", ts.expectedStatus, httpCode)
+			}
+			if httpCode != http.StatusOK {
+				return
+			}
+
+			if db.UtilizationQuery.RankBy != ts.expectedRankBy || db.UtilizationQuery.Top != ts.expectedTop {
+				t.Errorf("Expected rank by %s top %d but got %s top %d",
+					ts.expectedRankBy, ts.expectedTop, db.UtilizationQuery.RankBy, db.UtilizationQuery.Top)
+			}
+			if utilization.Total != 2 || len(utilization.Stations) != 2 || utilization.Stations[0].KioskID != 3005 {
+				t.Errorf("Expected 2 stations with kiosk 3005 first but got %+v", utilization.Stations)
+			}
+		})
+	}
+}
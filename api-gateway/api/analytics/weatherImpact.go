@@ -0,0 +1,56 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+// WeatherImpact returns the availability of the snapshots between q.From
+// and q.To banded by temperature, rain, wind and weather condition
+func (s *Service) WeatherImpact(q dbase.WeatherImpactQuery) (*WeatherImpact, int, error) {
+	if err := dbase.ValidateAnalyticsRange(q.From, q.To); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	rows, err := s.db.SearchWeatherImpact(context.Background(), q)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read weather impact")
+	}
+	if len(rows) == 0 {
+		return nil, http.StatusNotFound, errors.New("Data Not Found")
+	}
+
+	impact := WeatherImpact{
+		KioskID:     q.KioskID,
+		From:        q.From.UTC(),
+		To:          q.To.UTC(),
+		Temperature: []WeatherBand{},
+		Rain:        []WeatherBand{},
+		Wind:        []WeatherBand{},
+		Condition:   []WeatherBand{},
+	}
+
+	dimensions := map[string]*[]WeatherBand{
+		dbase.WeatherTemperature: &impact.Temperature,
+		dbase.WeatherRain:        &impact.Rain,
+		dbase.WeatherWind:        &impact.Wind,
+		dbase.WeatherCondition:   &impact.Condition,
+	}
+	for _, row := range rows {
+		bands, ok := dimensions[row.Dimension]
+		if !ok {
+			continue
+		}
+		*bands = append(*bands, WeatherBand{
+			Band:     row.Band,
+			Samples:  row.Samples,
+			BikesAvg: row.BikesAvg,
+			Turnover: row.Turnover,
+		})
+	}
+
+	return &impact, http.StatusOK, nil
+}
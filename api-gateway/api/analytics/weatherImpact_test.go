@@ -0,0 +1,55 @@
+package analytics
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/arthben/BackendGolang/api-gateway/internal/database/dbtest"
+)
+
+func TestWeatherImpact(t *testing.T) {
+	from := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	kioskID := 3005
+
+	db := &dbtest.FakeDB{WeatherImpact: []*dbase.WeatherImpact{
+		{Dimension: dbase.WeatherCondition, Band: "Clear", Samples: 10, BikesAvg: 4, Turnover: 1.5},
+		{Dimension: dbase.WeatherCondition, Band: "Rain", Samples: 4, BikesAvg: 7, Turnover: 0.25},
+		{Dimension: dbase.WeatherRain, Band: "none", Samples: 10, BikesAvg: 4, Turnover: 1.5},
+		{Dimension: dbase.WeatherRain, Band: "light", Samples: 4, BikesAvg: 7, Turnover: 0.25},
+		{Dimension: dbase.WeatherTemperature, Band: "5..10", BandOrder: 5, Samples: 14, BikesAvg: 4.86, Turnover: 1.14},
+		{Dimension: dbase.WeatherWind, Band: "light", Samples: 14, BikesAvg: 4.86, Turnover: 1.14},
+	}}
+
+	impact, httpCode, _ := NewService(db).WeatherImpact(dbase.WeatherImpactQuery{KioskID: &kioskID, From: from, To: from.Add(24 * time.Hour)})
+	if httpCode != http.StatusOK {
+		t.Fatalf("Expected status %d but got response %d", http.StatusOK, httpCode)
+	}
+
+	expectedRain := []WeatherBand{
+		{Band: "none", Samples: 10, BikesAvg: 4, Turnover: 1.5},
+		{Band: "light", Samples: 4, BikesAvg: 7, Turnover: 0.25},
+	}
+	if !reflect.DeepEqual(impact.Rain, expectedRain) {
+		t.Errorf("Expected rain bands %+v but got %+v", expectedRain, impact.Rain)
+	}
+	if len(impact.Condition) != 2 || len(impact.Temperature) != 1 || len(impact.Wind) != 1 {
+		t.Errorf("Expected 2 condition, 1 temperature and 1 wind bands but got %d, %d and %d",
+			len(impact.Condition), len(impact.Temperature), len(impact.Wind))
+	}
+	if impact.KioskID == nil || *impact.KioskID != kioskID {
+		t.Errorf("Expected kioskId %d", kioskID)
+	}
+
+	_, httpCode, _ = NewService(&dbtest.FakeDB{}).WeatherImpact(dbase.WeatherImpactQuery{From: from, To: from.Add(time.Hour)})
+	if httpCode != http.StatusNotFound {
+		t.Errorf("Expected status %d but got response %d", http.StatusNotFound, httpCode)
+	}
+
+	_, httpCode, _ = NewService(db).WeatherImpact(dbase.WeatherImpactQuery{From: from, To: from.Add(dbase.MaxAnalyticsRange + time.Hour)})
+	if httpCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d but got response %d", http.StatusBadRequest, httpCode)
+	}
+}
@@ -0,0 +1,102 @@
+package anomalies
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/internal/config"
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+const (
+	// DefaultStuckSnapshots is the number of snapshots with unchanged counts
+	// to flag a station stuck, when not configured
+	DefaultStuckSnapshots = 24
+	// MaxAnomalies limit the anomalies of one response
+	MaxAnomalies = 1000
+	// maxOpenAnomalies limit the open anomalies read by the detector
+	maxOpenAnomalies = 100000
+)
+
+var anomalyKinds = map[string]bool{
+	"":                       true,
+	dbase.AnomalyStuck:       true,
+	dbase.AnomalyOffline:     true,
+	dbase.AnomalyUnavailable: true,
+}
+
+type Service struct {
+	db             dbase.DBService
+	stuckSnapshots int
+}
+
+func NewService(db dbase.DBService, cfg *config.EnvParams) *Service {
+	stuckSnapshots := cfg.Anomaly.StuckSnapshots
+	if stuckSnapshots <= 0 {
+		stuckSnapshots = DefaultStuckSnapshots
+	}
+
+	return &Service{db: db, stuckSnapshots: stuckSnapshots}
+}
+
+// Detect check the stations of the snapshot at, open the new anomalies and
+// close the ones which ended
+func (s *Service) Detect(at time.Time) error {
+	ctx := context.Background()
+
+	samples, err := s.db.SearchAnomalySamples(ctx, at, s.stuckSnapshots)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+
+	open := true
+	current, err := s.db.SearchStationAnomalies(ctx, dbase.AnomalyQuery{Open: &open, Limit: maxOpenAnomalies})
+	if err != nil {
+		return err
+	}
+
+	changes := detect(samples, current, s.stuckSnapshots)
+	if len(changes) == 0 {
+		return nil
+	}
+	return s.db.SaveStationAnomalies(ctx, changes)
+}
+
+// List returns the anomalies of q, the last opened first
+func (s *Service) List(q dbase.AnomalyQuery) (*Anomalies, int, error) {
+	if !anomalyKinds[q.Kind] {
+		return nil, http.StatusBadRequest, errors.New("Invalid kind, use stuck, offline or unavailable")
+	}
+	q.Limit = MaxAnomalies
+
+	rows, err := s.db.SearchStationAnomalies(context.Background(), q)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read anomalies")
+	}
+
+	result := Anomalies{Anomalies: make([]Anomaly, 0, len(rows))}
+	for _, row := range rows {
+		anomaly := Anomaly{
+			AnomalyID:  row.AnomalyID,
+			KioskID:    row.KioskID,
+			Name:       row.Name,
+			Kind:       row.Kind,
+			Detail:     row.Detail,
+			Open:       row.ClosedAt == nil,
+			OpenedAt:   row.OpenedAt.UTC(),
+			LastSeenAt: row.LastSeenAt.UTC(),
+		}
+		if row.ClosedAt != nil {
+			closedAt := row.ClosedAt.UTC()
+			anomaly.ClosedAt = &closedAt
+		}
+		result.Anomalies = append(result.Anomalies, anomaly)
+	}
+
+	return &result, http.StatusOK, nil
+}
@@ -0,0 +1,148 @@
+package anomalies
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+type anomalyKey struct {
+	kioskID int
+	kind    string
+}
+
+// detect compare the anomalies found in samples with the open ones and
+// returns the anomalies to save: the new ones, the open ones still found
+// with their last seen updated and the open ones not found anymore closed.
+// Samples are ordered by station then newest first, only the stations on
+// the newest snapshot are checked
+func detect(samples []*dbase.AnomalySample, open []*dbase.StationAnomaly, stuckSnapshots int) []*dbase.StationAnomaly {
+	var latest time.Time
+	for _, sample := range samples {
+		if sample.At.After(latest) {
+			latest = sample.At
+		}
+	}
+
+	var (
+		found   = make(map[anomalyKey]*dbase.StationAnomaly)
+		checked = make(map[int]bool)
+	)
+	for _, station := range groupByStation(samples) {
+		if !station[0].At.Equal(latest) {
+			continue
+		}
+		checked[station[0].KioskID] = true
+
+		for _, anomaly := range checkStation(station, stuckSnapshots) {
+			anomaly.LastSeenAt = latest
+			found[anomalyKey{anomaly.KioskID, anomaly.Kind}] = anomaly
+		}
+	}
+
+	var changes []*dbase.StationAnomaly
+	for _, anomaly := range open {
+		key := anomalyKey{anomaly.KioskID, anomaly.Kind}
+		if current, ok := found[key]; ok {
+			anomaly.Detail = current.Detail
+			anomaly.LastSeenAt = latest
+			changes = append(changes, anomaly)
+			delete(found, key)
+			continue
+		}
+
+		// a station missing on the newest snapshot is not checked, keep it open
+		if checked[anomaly.KioskID] {
+			closedAt := latest
+			anomaly.ClosedAt = &closedAt
+			changes = append(changes, anomaly)
+		}
+	}
+
+	for _, anomaly := range found {
+		changes = append(changes, anomaly)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].KioskID != changes[j].KioskID {
+			return changes[i].KioskID < changes[j].KioskID
+		}
+		return changes[i].Kind < changes[j].Kind
+	})
+	return changes
+}
+
+// checkStation returns the anomalies of one station, samples newest first.
+// An anomaly is opened at the oldest of the consecutive samples with it
+func checkStation(samples []*dbase.AnomalySample, stuckSnapshots int) []*dbase.StationAnomaly {
+	var (
+		anomalies []*dbase.StationAnomaly
+		newest    = samples[0]
+	)
+
+	add := func(kind string, detail string, since func(*dbase.AnomalySample) bool) {
+		openedAt := newest.At
+		for _, sample := range samples {
+			if !since(sample) {
+				break
+			}
+			openedAt = sample.At
+		}
+
+		anomalies = append(anomalies, &dbase.StationAnomaly{
+			KioskID:  newest.KioskID,
+			Name:     newest.Name,
+			Kind:     kind,
+			Detail:   detail,
+			OpenedAt: openedAt,
+		})
+	}
+
+	sameCounts := func(sample *dbase.AnomalySample) bool {
+		return sample.BikesAvailable == newest.BikesAvailable && sample.DocksAvailable == newest.DocksAvailable
+	}
+	if stuckSnapshots > 0 && len(samples) >= stuckSnapshots {
+		stuck := true
+		for _, sample := range samples[:stuckSnapshots] {
+			stuck = stuck && sameCounts(sample)
+		}
+		if stuck {
+			add(dbase.AnomalyStuck, fmt.Sprintf("bikesAvailable %d and docksAvailable %d unchanged",
+				newest.BikesAvailable, newest.DocksAvailable), sameCounts)
+		}
+	}
+
+	if offline(newest) {
+		add(dbase.AnomalyOffline, "kioskConnectionStatus "+newest.ConnectionStatus, offline)
+	}
+
+	if unavailable(newest) {
+		add(dbase.AnomalyUnavailable, fmt.Sprintf("kioskStatus %s, kioskPublicStatus %s",
+			newest.KioskStatus, newest.PublicStatus), unavailable)
+	}
+
+	return anomalies
+}
+
+func offline(sample *dbase.AnomalySample) bool {
+	return len(sample.ConnectionStatus) > 0 && sample.ConnectionStatus != "Active"
+}
+
+func unavailable(sample *dbase.AnomalySample) bool {
+	return sample.KioskStatus == "Unavailable" || sample.PublicStatus == "Unavailable"
+}
+
+// groupByStation split samples ordered by station into one slice per
+// station
+func groupByStation(samples []*dbase.AnomalySample) [][]*dbase.AnomalySample {
+	var stations [][]*dbase.AnomalySample
+	for i, sample := range samples {
+		if i == 0 || sample.KioskID != samples[i-1].KioskID {
+			stations = append(stations, nil)
+		}
+		stations[len(stations)-1] = append(stations[len(stations)-1], sample)
+	}
+	return stations
+}
@@ -0,0 +1,99 @@
+package anomalies
+
+import (
+	"testing"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+// snapshots returns n samples of one station every hour until at, newest
+// first, all with the same counts and statuses
+func snapshots(kioskID int, at time.Time, n int) []*dbase.AnomalySample {
+	var samples []*dbase.AnomalySample
+	for i := 0; i < n; i++ {
+		samples = append(samples, &dbase.AnomalySample{
+			KioskID:          kioskID,
+			At:               at.Add(-time.Duration(i) * time.Hour),
+			Name:             "Station",
+			BikesAvailable:   3,
+			DocksAvailable:   12,
+			KioskStatus:      "FullService",
+			PublicStatus:     "Active",
+			ConnectionStatus: "Active",
+		})
+	}
+	return samples
+}
+
+func TestDetect(t *testing.T) {
+	at := time.Date(2024, 11, 8, 12, 0, 0, 0, time.UTC)
+
+	// 3005 stuck for 4 snapshots, 3006 moving and offline for 2 snapshots,
+	// 3007 not on the newest snapshot
+	moving := snapshots(3006, at, 4)
+	for i, sample := range moving {
+		sample.BikesAvailable = i
+		if i < 2 {
+			sample.ConnectionStatus = "Inactive"
+		}
+	}
+	samples := append(snapshots(3005, at, 4), moving...)
+	samples = append(samples, snapshots(3007, at.Add(-time.Hour), 3)...)
+
+	changes := detect(samples, nil, 3)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 anomalies but got %d", len(changes))
+	}
+
+	stuck, offline := changes[0], changes[1]
+	if stuck.KioskID != 3005 || stuck.Kind != dbase.AnomalyStuck || !stuck.OpenedAt.Equal(at.Add(-3*time.Hour)) {
+		t.Errorf("Expected 3005 stuck since 09:00 but got %+v", stuck)
+	}
+	if offline.KioskID != 3006 || offline.Kind != dbase.AnomalyOffline || !offline.OpenedAt.Equal(at.Add(-time.Hour)) {
+		t.Errorf("Expected 3006 offline since 11:00 but got %+v", offline)
+	}
+	if !stuck.LastSeenAt.Equal(at) || stuck.ClosedAt != nil {
+		t.Errorf("Expected 3005 open and last seen at %s but got %+v", at, stuck)
+	}
+}
+
+func TestDetectOpenAnomalies(t *testing.T) {
+	at := time.Date(2024, 11, 8, 12, 0, 0, 0, time.UTC)
+	openedAt := at.Add(-48 * time.Hour)
+
+	open := []*dbase.StationAnomaly{
+		// still stuck
+		{AnomalyID: 1, KioskID: 3005, Kind: dbase.AnomalyStuck, OpenedAt: openedAt, LastSeenAt: openedAt},
+		// back online
+		{AnomalyID: 2, KioskID: 3005, Kind: dbase.AnomalyOffline, OpenedAt: openedAt, LastSeenAt: openedAt},
+		// not on the newest snapshot
+		{AnomalyID: 3, KioskID: 3007, Kind: dbase.AnomalyUnavailable, OpenedAt: openedAt, LastSeenAt: openedAt},
+	}
+
+	samples := append(snapshots(3005, at, 3), snapshots(3007, at.Add(-time.Hour), 3)...)
+	changes := detect(samples, open, 3)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes but got %d", len(changes))
+	}
+
+	offline, stuck := changes[0], changes[1]
+	if stuck.AnomalyID != 1 || !stuck.LastSeenAt.Equal(at) || stuck.ClosedAt != nil || !stuck.OpenedAt.Equal(openedAt) {
+		t.Errorf("Expected anomaly 1 open and last seen at %s but got %+v", at, stuck)
+	}
+	if offline.AnomalyID != 2 || offline.ClosedAt == nil || !offline.ClosedAt.Equal(at) {
+		t.Errorf("Expected anomaly 2 closed at %s but got %+v", at, offline)
+	}
+}
+
+func TestDetectUnavailable(t *testing.T) {
+	at := time.Date(2024, 11, 8, 12, 0, 0, 0, time.UTC)
+
+	samples := snapshots(3005, at, 2)
+	samples[0].KioskStatus = "Unavailable"
+
+	changes := detect(samples, nil, 3)
+	if len(changes) != 1 || changes[0].Kind != dbase.AnomalyUnavailable || !changes[0].OpenedAt.Equal(at) {
+		t.Errorf("Expected 3005 unavailable since %s but got %+v", at, changes)
+	}
+}
@@ -0,0 +1,23 @@
+package anomalies
+
+import (
+	"time"
+)
+
+// Anomaly is a period where a station was stuck, offline or unavailable.
+// ClosedAt is not set while the anomaly is open
+type Anomaly struct {
+	AnomalyID  int        `json:"anomalyId"`
+	KioskID    int        `json:"kioskId"`
+	Name       string     `json:"name"`
+	Kind       string     `json:"kind"`
+	Detail     string     `json:"detail"`
+	Open       bool       `json:"open"`
+	OpenedAt   time.Time  `json:"openedAt"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	ClosedAt   *time.Time `json:"closedAt,omitempty"`
+}
+
+type Anomalies struct {
+	Anomalies []Anomaly `json:"anomalies"`
+}
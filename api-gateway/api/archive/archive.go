@@ -0,0 +1,36 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	db dbase.DBService
+}
+
+func NewService(db dbase.DBService) *Service {
+	return &Service{db: db}
+}
+
+// Raw returns the upstream body stored with the given fetch_id
+func (a *Service) Raw(fetchID string) (*dbase.RawPayload, int, error) {
+	if _, err := uuid.Parse(fetchID); err != nil {
+		return nil, http.StatusBadRequest, errors.New("Invalid fetchId format")
+	}
+
+	raw, err := a.db.SearchRawPayload(context.Background(), fetchID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, http.StatusNotFound, errors.New("Raw payload not found")
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read raw payload")
+	}
+
+	return raw, http.StatusOK, nil
+}
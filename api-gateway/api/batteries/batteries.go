@@ -0,0 +1,148 @@
+package batteries
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/internal/config"
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+// DefaultLowThreshold is the battery percent below which an electric bike
+// needs a swap, when not configured
+const DefaultLowThreshold = 20
+
+type Service struct {
+	db           dbase.DBService
+	lowThreshold int
+}
+
+func NewService(db dbase.DBService, cfg *config.EnvParams) *Service {
+	lowThreshold := cfg.Battery.LowThreshold
+	if lowThreshold <= 0 || lowThreshold > 100 {
+		lowThreshold = DefaultLowThreshold
+	}
+
+	return &Service{db: db, lowThreshold: lowThreshold}
+}
+
+// Snapshot returns the batteries of the snapshot selected by q, of one
+// station when kioskID is not nil
+func (s *Service) Snapshot(q dbase.SnapshotQuery, kioskID *int) (*Batteries, int, error) {
+	return s.search(dbase.BatteryQuery{Snapshot: &q, KioskID: kioskID})
+}
+
+// Trend returns the batteries of the snapshots between from and to
+func (s *Service) Trend(from time.Time, to time.Time, kioskID *int) (*Batteries, int, error) {
+	if err := dbase.ValidateAnalyticsRange(from, to); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	return s.search(dbase.BatteryQuery{From: from, To: to, KioskID: kioskID})
+}
+
+func (s *Service) search(q dbase.BatteryQuery) (*Batteries, int, error) {
+	q.LowThreshold = s.lowThreshold
+
+	result, err := s.db.SearchBatteries(context.Background(), q)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, http.StatusNotFound, errors.New("Data Not Found")
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read batteries")
+	}
+	if len(result.Trend) == 0 {
+		return nil, http.StatusNotFound, errors.New("Data Not Found")
+	}
+
+	batteries := summarize(result, s.lowThreshold)
+	batteries.KioskID = q.KioskID
+	return batteries, http.StatusOK, nil
+}
+
+// summarize average the counts of the stations over the snapshots of the
+// trend, a station without electric bikes on a snapshot counts zero
+func summarize(result dbase.SearchResBatteries, lowThreshold int) *Batteries {
+	snapshots := float64(len(result.Trend))
+
+	batteries := Batteries{
+		From:         result.From.UTC(),
+		To:           result.To.UTC(),
+		Snapshots:    len(result.Trend),
+		LowThreshold: lowThreshold,
+		Stations:     make([]StationBatteries, 0, len(result.Stations)),
+		Trend:        make([]BatteryPoint, 0, len(result.Trend)),
+	}
+
+	var electricBikes, lowBattery int
+	var sumBattery float64
+	for _, row := range result.Trend {
+		electricBikes += row.ElectricBikes
+		lowBattery += row.LowBattery
+		sumBattery += row.AvgBattery * float64(row.ElectricBikes)
+
+		batteries.Trend = append(batteries.Trend, BatteryPoint{
+			At:            row.At.UTC(),
+			ElectricBikes: row.ElectricBikes,
+			LowBattery:    row.LowBattery,
+			AvgBattery:    round2(row.AvgBattery),
+		})
+	}
+
+	batteries.ElectricBikes = round2(float64(electricBikes) / snapshots)
+	batteries.LowBattery = round2(float64(lowBattery) / snapshots)
+	if electricBikes > 0 {
+		batteries.AvgBattery = round2(sumBattery / float64(electricBikes))
+	}
+
+	systemBands := make([]int64, len(dbase.BatteryBands))
+	for _, row := range result.Stations {
+		for i, bikes := range row.Bands {
+			if i < len(systemBands) {
+				systemBands[i] += bikes
+			}
+		}
+
+		batteries.Stations = append(batteries.Stations, StationBatteries{
+			KioskID:       row.KioskID,
+			Name:          row.Name,
+			ElectricBikes: round2(float64(row.ElectricBikes) / snapshots),
+			LowBattery:    round2(float64(row.LowBattery) / snapshots),
+			AvgBattery:    round2(row.AvgBattery),
+			Distribution:  distribution(row.Bands, snapshots),
+		})
+	}
+	batteries.Distribution = distribution(systemBands, snapshots)
+
+	return &batteries
+}
+
+// distribution label the counts of bands with dbase.BatteryBands
+func distribution(bands []int64, snapshots float64) []BatteryBand {
+	result := make([]BatteryBand, 0, len(dbase.BatteryBands))
+	for i, lower := range dbase.BatteryBands {
+		upper := 100
+		if i+1 < len(dbase.BatteryBands) {
+			upper = dbase.BatteryBands[i+1] - 1
+		}
+
+		var bikes int64
+		if i < len(bands) {
+			bikes = bands[i]
+		}
+		result = append(result, BatteryBand{
+			Band:  fmt.Sprintf("%d-%d", lower, upper),
+			Bikes: round2(float64(bikes) / snapshots),
+		})
+	}
+	return result
+}
+
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
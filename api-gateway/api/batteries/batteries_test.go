@@ -0,0 +1,102 @@
+package batteries
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/internal/config"
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/arthben/BackendGolang/api-gateway/internal/database/dbtest"
+)
+
+func TestTrend(t *testing.T) {
+	from := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	db := &dbtest.FakeDB{Batteries: dbase.SearchResBatteries{
+		From: from,
+		To:   from.Add(time.Hour),
+		Stations: []*dbase.StationBatteries{
+			{KioskID: 3005, Name: "Welcome Park", ElectricBikes: 3, LowBattery: 2, AvgBattery: 30, Bands: []int64{2, 0, 0, 1, 0}},
+			{KioskID: 3006, Name: "Ben Franklin", ElectricBikes: 1, LowBattery: 0, AvgBattery: 90, Bands: []int64{0, 0, 0, 0, 1}},
+		},
+		Trend: []*dbase.BatteryPoint{
+			{At: from, ElectricBikes: 3, LowBattery: 1, AvgBattery: 50},
+			{At: from.Add(time.Hour), ElectricBikes: 1, LowBattery: 1, AvgBattery: 10},
+		},
+	}}
+
+	batteries, httpCode, _ := NewService(db, &config.EnvParams{}).Trend(from, from.Add(time.Hour), nil)
+	if httpCode != http.StatusOK {
+		t.Fatalf("Expected status %d but got response %d", http.StatusOK, httpCode)
+	}
+
+	if db.BatteryQuery.LowThreshold != DefaultLowThreshold || batteries.LowThreshold != DefaultLowThreshold {
+		t.Errorf("Expected low threshold %d but got %d", DefaultLowThreshold, db.BatteryQuery.LowThreshold)
+	}
+	if batteries.Snapshots != 2 || batteries.ElectricBikes != 2 || batteries.LowBattery != 1 {
+		t.Errorf("Expected 2 snapshots, 2 bikes and 1 low battery but got %+v", batteries)
+	}
+	if batteries.AvgBattery != 40 {
+		t.Errorf("Expected average battery 40 but got %v", batteries.AvgBattery)
+	}
+
+	station := batteries.Stations[0]
+	if station.KioskID != 3005 || station.ElectricBikes != 1.5 || station.LowBattery != 1 {
+		t.Errorf("Expected 3005 with 1.5 bikes and 1 low battery but got %+v", station)
+	}
+	if len(station.Distribution) != 5 || station.Distribution[0].Band != "0-19" || station.Distribution[0].Bikes != 1 {
+		t.Errorf("Expected 1 bike in band 0-19 but got %+v", station.Distribution)
+	}
+	if last := batteries.Distribution[4]; last.Band != "80-100" || last.Bikes != 0.5 {
+		t.Errorf("Expected 0.5 bike in band 80-100 but got %+v", last)
+	}
+}
+
+func TestTrendErrors(t *testing.T) {
+	from := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	cfg := &config.EnvParams{}
+	cfg.Battery.LowThreshold = 30
+
+	scenarios := []struct {
+		name           string
+		db             *dbtest.FakeDB
+		to             time.Time
+		expectedStatus int
+	}{
+		{
+			name:           "Fail - Range reversed",
+			db:             &dbtest.FakeDB{},
+			to:             from.Add(-time.Hour),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - Range too long",
+			db:             &dbtest.FakeDB{},
+			to:             from.Add(32 * 24 * time.Hour),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - No snapshot",
+			db:             &dbtest.FakeDB{},
+			to:             from.Add(time.Hour),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Snapshot not found",
+			db:             &dbtest.FakeDB{BatteriesErr: sql.ErrNoRows},
+			to:             from.Add(time.Hour),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			_, httpCode, _ := NewService(ts.db, cfg).Trend(from, ts.to, nil)
+			if httpCode != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, httpCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package batteries
+
+import (
+	"time"
+)
+
+// BatteryBand is the electric bikes with a battery level in Band, averaged
+// over the snapshots
+type BatteryBand struct {
+	Band  string  `json:"band"`
+	Bikes float64 `json:"bikes"`
+}
+
+// StationBatteries is the electric bikes of one station averaged over the
+// snapshots. LowBattery is the bikes below the low threshold
+type StationBatteries struct {
+	KioskID       int           `json:"kioskId"`
+	Name          string        `json:"name"`
+	ElectricBikes float64       `json:"electricBikes"`
+	LowBattery    float64       `json:"lowBattery"`
+	AvgBattery    float64       `json:"avgBattery"`
+	Distribution  []BatteryBand `json:"distribution"`
+}
+
+// BatteryPoint is the electric bikes of all stations on one snapshot
+type BatteryPoint struct {
+	At            time.Time `json:"at"`
+	ElectricBikes int       `json:"electricBikes"`
+	LowBattery    int       `json:"lowBattery"`
+	AvgBattery    float64   `json:"avgBattery"`
+}
+
+type Batteries struct {
+	KioskID       *int               `json:"kioskId,omitempty"`
+	From          time.Time          `json:"from"`
+	To            time.Time          `json:"to"`
+	Snapshots     int                `json:"snapshots"`
+	LowThreshold  int                `json:"lowThreshold"`
+	ElectricBikes float64            `json:"electricBikes"`
+	LowBattery    float64            `json:"lowBattery"`
+	AvgBattery    float64            `json:"avgBattery"`
+	Distribution  []BatteryBand      `json:"distribution"`
+	Stations      []StationBatteries `json:"stations"`
+	Trend         []BatteryPoint     `json:"trend"`
+}
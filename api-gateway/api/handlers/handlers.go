@@ -1,32 +1,47 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"math"
 	"net/http"
 	"strconv"
-	"sync"
 	"time"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/arthben/BackendGolang/api-gateway/api/analytics"
+	"github.com/arthben/BackendGolang/api-gateway/api/anomalies"
+	"github.com/arthben/BackendGolang/api-gateway/api/archive"
+	"github.com/arthben/BackendGolang/api-gateway/api/batteries"
+	"github.com/arthben/BackendGolang/api-gateway/api/ingest"
+	"github.com/arthben/BackendGolang/api-gateway/api/latest"
 	"github.com/arthben/BackendGolang/api-gateway/api/middlewares"
 	"github.com/arthben/BackendGolang/api-gateway/api/openweather"
 	"github.com/arthben/BackendGolang/api-gateway/api/rideindego"
+	"github.com/arthben/BackendGolang/api-gateway/api/stats"
 	"github.com/arthben/BackendGolang/api-gateway/docs"
 	"github.com/arthben/BackendGolang/api-gateway/internal/config"
 	"github.com/arthben/BackendGolang/api-gateway/internal/database"
 	"github.com/gin-gonic/gin"
 )
 
-type fetchResult struct {
-	HTTPCode int
-	Error    error
-}
+// maxPolygonBody is the largest GeoJSON body accepted, in bytes
+const maxPolygonBody = 1 << 20
 
 type Handlers struct {
 	router      *gin.Engine
 	rideindego  *rideindego.Service
 	openweather *openweather.Service
+	archive     *archive.Service
+	ingest      *ingest.Service
+	jobs        *ingest.Jobs
+	latest      *latest.Cache
+	stats       *stats.Service
+	analytics   *analytics.Service
+	anomalies   *anomalies.Service
+	batteries   *batteries.Service
 }
 
 func Barusaja() {
@@ -34,14 +49,36 @@ func Barusaja() {
 }
 
 func NewHandler(db database.DBService, cfg *config.EnvParams) *Handlers {
+	// the latest endpoints serve the snapshot refreshed by the ingestion
+	cache := latest.NewCache(db, cfg)
+	ingestService := ingest.NewService(db, cfg, cache)
 
 	return &Handlers{
 		router:      gin.New(),
 		rideindego:  rideindego.NewService(db),
 		openweather: openweather.NewService(db, cfg),
+		archive:     archive.NewService(db),
+		ingest:      ingestService,
+		jobs:        ingest.NewJobs(db, ingestService),
+		latest:      cache,
+		stats:       stats.NewService(db),
+		analytics:   analytics.NewService(db),
+		anomalies:   anomalies.NewService(db, cfg),
+		batteries:   batteries.NewService(db, cfg),
 	}
 }
 
+// Ingest returns the ingest service of the handlers, the scheduler share it
+// so its runs never overlap with the fetch requests
+func (h *Handlers) Ingest() *ingest.Service {
+	return h.ingest
+}
+
+// Close wait for the running ingestion job and stop the job worker
+func (h *Handlers) Close() {
+	h.jobs.Stop()
+}
+
 func (h *Handlers) BuildHandler() (http.Handler, error) {
 	h.setupMiddlewares()
 	h.setupSwagger()
@@ -50,7 +87,27 @@ func (h *Handlers) BuildHandler() (http.Handler, error) {
 	{
 		apiv1.POST("/indego-data-fetch-and-store-it-db", h.FetchAndStoreIndego)
 		apiv1.GET("/stations", h.FindSpecifTime)
+		apiv1.GET("/stations/latest", h.FindLatest)
+		apiv1.GET("/stations/nearby", h.FindNearby)
+		apiv1.GET("/stations/within", h.FindWithinBBox)
+		apiv1.GET("/stations/diff", h.FindDiff)
+		apiv1.GET("/stations/stats", h.FindSystemStats)
+		apiv1.POST("/stations/within", h.FindWithinPolygon)
 		apiv1.GET("/stations/:kioskId", h.FindKioskWithTime)
+		apiv1.GET("/stations/:kioskId/history", h.FindKioskHistory)
+		apiv1.GET("/stations/:kioskId/latest", h.FindKioskLatest)
+		apiv1.GET("/stations/:kioskId/stats", h.FindKioskStats)
+		apiv1.GET("/stations/:kioskId/forecast", h.FindKioskForecast)
+		apiv1.GET("/snapshots/:fetchId/raw", h.FindRawPayload)
+		apiv1.GET("/jobs/:jobId", h.FindJob)
+		apiv1.GET("/ingestion-runs", h.FindIngestionRuns)
+		apiv1.GET("/ingestion-runs/latest", h.FindLatestIngestionRuns)
+		apiv1.GET("/analytics/utilization", h.FindUtilization)
+		apiv1.GET("/analytics/weather-impact", h.FindWeatherImpact)
+		apiv1.GET("/analytics/flows", h.FindFlows)
+		apiv1.GET("/analytics/batteries", h.FindBatteries)
+		apiv1.GET("/analytics/batteries/trend", h.FindBatteriesTrend)
+		apiv1.GET("/anomalies", h.FindAnomalies)
 	}
 
 	return http.Handler(h.router), nil
@@ -67,59 +124,34 @@ func (h *Handlers) setupSwagger() {
 	h.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 }
 
-func (h *Handlers) fetchIndego() fetchResult {
-	httCode, err := h.rideindego.FetchAndStore()
-	return fetchResult{HTTPCode: httCode, Error: err}
-}
-
-func (h *Handlers) fetchWeather() fetchResult {
-	httpCode, err := h.openweather.FetchAndStore()
-	return fetchResult{HTTPCode: httpCode, Error: err}
-}
-
 // FetchAndStoreIndego godoc
 // @Summary Store data from Indego
 // @Description.markdown data-fetch
 // @Tags API
 // @Produce json
 // @Param Authorization header string true "Bearer secret_token_static"
+// @Param async         query  bool   false "ex: true"
 // @Router /api/v1/indego-data-fetch-and-store-it-db [post]
 func (h *Handlers) FetchAndStoreIndego(c *gin.Context) {
-	var (
-		waitGroup sync.WaitGroup
-		chIndego  = make(chan fetchResult)
-		chWeather = make(chan fetchResult)
-	)
-
-	waitGroup.Add(3)
-
-	go func() {
-		waitGroup.Wait()
-		close(chIndego)
-		close(chWeather)
-	}()
-
-	go func() {
-		defer waitGroup.Done()
-		result := h.fetchIndego()
-		chIndego <- result
-	}()
+	if c.Query("async") == "true" {
+		job, httpCode, err := h.jobs.Submit()
+		if err != nil {
+			c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+			return
+		}
 
-	go func() {
-		defer waitGroup.Done()
-		result := h.fetchWeather()
-		chWeather <- result
-	}()
-
-	_ = <-chWeather
-	resIndego := <-chIndego
-
-	if resIndego.Error != nil {
-		c.AbortWithStatusJSON(resIndego.HTTPCode, gin.H{"error": resIndego.Error})
+		c.Header("Location", "/api/v1/jobs/"+job.JobID)
+		c.JSON(httpCode, job)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "Fetch and store success"})
+	result := h.ingest.FetchAndStore()
+
+	c.JSON(result.HTTPStatus(), gin.H{
+		"status":      result.Message(),
+		"rideindego":  result.RideIndego,
+		"openweather": result.OpenWeather,
+	})
 }
 
 // FindKioskWithTime godoc
@@ -128,8 +160,10 @@ func (h *Handlers) FetchAndStoreIndego(c *gin.Context) {
 // @Tags API
 // @Produce json
 // @Param Authorization header string true "Bearer secret_token_static"
-// @Param at            query  string true "ex: 2019-09-01T10:00:00Z"
-// @Param kioskId       path   string true "ex: 3005"
+// @Param at            query  string true  "ex: 2019-09-01T10:00:00Z"
+// @Param kioskId       path   string true  "ex: 3005"
+// @Param mode          query  string false "after, before or nearest"
+// @Param maxAge        query  string false "ex: 2h"
 // @Router /api/v1/stations/{kioskId} [get]
 func (h *Handlers) FindKioskWithTime(c *gin.Context) {
 	kioskId := c.Param("kioskId")
@@ -150,13 +184,21 @@ func (h *Handlers) FindKioskWithTime(c *gin.Context) {
 		return
 	}
 
-	jsonIndego, httpCode, err := h.rideindego.Search(at, kioskId)
+	query, err := snapshotQuery(c, at)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	jsonIndego, httpCode, err := h.rideindego.Search(query, kioskId, database.PropertiesFilter{})
 	if err != nil {
 		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
 		return
 	}
 
-	jsonWeather, httpCode, err := h.openweather.Search(at)
+	// the weather is selected relative to the snapshot found
+	query.At = jsonIndego.LastUpdated
+	jsonWeather, httpCode, err := h.openweather.Search(query)
 	if err != nil {
 		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
 		return
@@ -169,13 +211,402 @@ func (h *Handlers) FindKioskWithTime(c *gin.Context) {
 	})
 }
 
+// FindLatest godoc
+// @Summary Latest snapshot of all stations
+// @Description.markdown stationsLatest
+// @Tags API
+// @Produce json
+// @Param Authorization header string true "Bearer secret_token_static"
+// @Router /api/v1/stations/latest [get]
+func (h *Handlers) FindLatest(c *gin.Context) {
+	snapshot, httpCode, err := h.latest.Latest("")
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// FindKioskLatest godoc
+// @Summary Latest snapshot of one station
+// @Description.markdown stationsKioskLatest
+// @Tags API
+// @Produce json
+// @Param Authorization header string true "Bearer secret_token_static"
+// @Param kioskId       path   string true "ex: 3005"
+// @Router /api/v1/stations/{kioskId}/latest [get]
+func (h *Handlers) FindKioskLatest(c *gin.Context) {
+	snapshot, httpCode, err := h.latest.Latest(c.Param("kioskId"))
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// FindNearby godoc
+// @Summary Stations near a point
+// @Description.markdown stationsNearby
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param lat           query  number true  "ex: 39.9526"
+// @Param lon           query  number true  "ex: -75.1652"
+// @Param radius        query  number false "ex: 500"
+// @Param at            query  string false "ex: 2019-09-01T10:00:00Z"
+// @Param mode          query  string false "after, before or nearest"
+// @Param maxAge        query  string false "ex: 2h"
+// @Param kioskStatus   query  string false "ex: FullService"
+// @Param minBikes      query  int    false "ex: 3"
+// @Param minDocks      query  int    false "ex: 2"
+// @Param isVirtual     query  bool   false "ex: false"
+// @Param zip           query  string false "ex: 19106"
+// @Param kioskType     query  int    false "ex: 1"
+// @Param kioskIds      query  string false "ex: 3005,3006"
+// @Param limit         query  int    false "ex: 10"
+// @Param offset        query  int    false "ex: 0"
+// @Router /api/v1/stations/nearby [get]
+func (h *Handlers) FindNearby(c *gin.Context) {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lon, errLon := strconv.ParseFloat(c.Query("lon"), 64)
+	// ParseFloat accepts NaN and Inf, NaN passes any range check
+	if errLat != nil || errLon != nil || !isFinite(lat) || !isFinite(lon) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid lat or lon"})
+		return
+	}
+
+	nearby := database.NearbyQuery{Lat: lat, Lon: lon, Radius: rideindego.DefaultNearbyRadius}
+	if q := c.Query("radius"); len(q) > 0 {
+		radius, err := strconv.ParseFloat(q, 64)
+		if err != nil || !isFinite(radius) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid radius format"})
+			return
+		}
+		nearby.Radius = radius
+	}
+
+	filter, err := rideindego.ParseFilter(c.Request.URL.Query())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// stations are sorted by distance, the other parameters apply as is
+	if len(filter.Sort) > 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "sort can not be used with nearby, stations are sorted by distance"})
+		return
+	}
+	nearby.Filter = filter
+	nearby.Limit, nearby.Offset = filter.Limit, filter.Offset
+
+	query, err := latestSnapshotQuery(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stations, httpCode, err := h.rideindego.Nearby(query, nearby)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stations)
+}
+
+// isFinite reports whether f is neither NaN nor an infinity
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// snapshotQuery read parameter mode and maxAge of the station endpoints
+func snapshotQuery(c *gin.Context, at time.Time) (database.SnapshotQuery, error) {
+	query := database.SnapshotQuery{At: at, Mode: c.Query("mode")}
+	if !database.ValidSnapshotMode(query.Mode) {
+		return query, errors.New("Invalid mode, use after, before or nearest")
+	}
+
+	if q := c.Query("maxAge"); len(q) > 0 {
+		maxAge, err := time.ParseDuration(q)
+		if err != nil || maxAge <= 0 {
+			return query, errors.New("Invalid maxAge format")
+		}
+		query.MaxAge = maxAge
+	}
+
+	return query, nil
+}
+
+// FindWithinBBox godoc
+// @Summary Stations inside a bounding box
+// @Description.markdown stationsWithin
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param bbox          query  string true  "ex: -75.18,39.94,-75.14,39.96"
+// @Param at            query  string false "ex: 2019-09-01T10:00:00Z"
+// @Param mode          query  string false "after, before or nearest"
+// @Param maxAge        query  string false "ex: 2h"
+// @Router /api/v1/stations/within [get]
+func (h *Handlers) FindWithinBBox(c *gin.Context) {
+	area, err := rideindego.BBoxArea(c.Query("bbox"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.findWithin(c, area)
+}
+
+// FindWithinPolygon godoc
+// @Summary Stations inside a GeoJSON Polygon or MultiPolygon
+// @Description.markdown stationsWithinPolygon
+// @Tags API
+// @Accept json
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param at            query  string false "ex: 2019-09-01T10:00:00Z"
+// @Param mode          query  string false "after, before or nearest"
+// @Param maxAge        query  string false "ex: 2h"
+// @Param polygon       body   object true  "GeoJSON Polygon or MultiPolygon"
+// @Router /api/v1/stations/within [post]
+func (h *Handlers) FindWithinPolygon(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPolygonBody+1))
+	if err != nil || len(body) > maxPolygonBody {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid GeoJSON"})
+		return
+	}
+
+	area, err := rideindego.PolygonArea(body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.findWithin(c, area)
+}
+
+// findWithin respond the stations of the selected snapshot inside area as
+// GeoJSON FeatureCollection
+func (h *Handlers) findWithin(c *gin.Context, area string) {
+	query, err := latestSnapshotQuery(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := rideindego.ParseFilter(c.Request.URL.Query())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Area = area
+
+	jsonIndego, httpCode, err := h.rideindego.Search(query, "", filter)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jsonIndego)
+}
+
+// latestSnapshotQuery is snapshotQuery with optional at, without at the
+// latest snapshot is selected
+func latestSnapshotQuery(c *gin.Context) (database.SnapshotQuery, error) {
+	q := c.Query("at")
+	if len(q) == 0 {
+		return database.SnapshotQuery{At: time.Now().UTC(), Mode: database.ModeBefore}, nil
+	}
+
+	at, err := time.Parse(time.RFC3339, q)
+	if err != nil {
+		return database.SnapshotQuery{}, errors.New("Invalid timestamp format")
+	}
+
+	return snapshotQuery(c, at)
+}
+
+// FindKioskHistory godoc
+// @Summary Availability history of one station
+// @Description.markdown stationsHistory
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param kioskId       path   string true  "ex: 3005"
+// @Param from          query  string true  "ex: 2024-11-01T00:00:00Z"
+// @Param to            query  string true  "ex: 2024-11-02T00:00:00Z"
+// @Param interval      query  string false "ex: 1h"
+// @Router /api/v1/stations/{kioskId}/history [get]
+func (h *Handlers) FindKioskHistory(c *gin.Context) {
+	kioskId, err := strconv.Atoi(c.Param("kioskId"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid kioskId format"})
+		return
+	}
+
+	from, to, err := timeRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var interval time.Duration
+	if q := c.Query("interval"); len(q) > 0 {
+		if interval, err = time.ParseDuration(q); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid interval format"})
+			return
+		}
+	}
+
+	history, httpCode, err := h.rideindego.History(kioskId, from, to, interval)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// timeRange read the required parameter from and to
+func timeRange(c *gin.Context) (time.Time, time.Time, error) {
+	qFrom, qTo := c.Query("from"), c.Query("to")
+	if len(qFrom) == 0 || len(qTo) == 0 {
+		return time.Time{}, time.Time{}, errors.New("Invalid Request Parameter")
+	}
+
+	from, err := time.Parse(time.RFC3339, qFrom)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("Invalid timestamp format")
+	}
+
+	to, err := time.Parse(time.RFC3339, qTo)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("Invalid timestamp format")
+	}
+
+	return from, to, nil
+}
+
+// FindKioskStats godoc
+// @Summary Hourly or daily stats of one station
+// @Description.markdown stationsKioskStats
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param kioskId       path   string true  "ex: 3005"
+// @Param granularity   query  string false "hour or day"
+// @Param from          query  string true  "ex: 2024-11-01T00:00:00Z"
+// @Param to            query  string true  "ex: 2024-11-02T00:00:00Z"
+// @Router /api/v1/stations/{kioskId}/stats [get]
+func (h *Handlers) FindKioskStats(c *gin.Context) {
+	kioskId, err := strconv.Atoi(c.Param("kioskId"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid kioskId format"})
+		return
+	}
+
+	from, to, err := timeRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, httpCode, err := h.stats.Station(kioskId, c.Query("granularity"), from, to)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// FindSystemStats godoc
+// @Summary Hourly or daily stats of all stations
+// @Description.markdown stationsStats
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param granularity   query  string false "hour or day"
+// @Param from          query  string true  "ex: 2024-11-01T00:00:00Z"
+// @Param to            query  string true  "ex: 2024-11-02T00:00:00Z"
+// @Router /api/v1/stations/stats [get]
+func (h *Handlers) FindSystemStats(c *gin.Context) {
+	from, to, err := timeRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, httpCode, err := h.stats.System(c.Query("granularity"), from, to)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// FindDiff godoc
+// @Summary Change of the stations between two snapshots
+// @Description.markdown stationsDiff
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param from          query  string true  "ex: 2024-11-08T07:00:00Z"
+// @Param to            query  string true  "ex: 2024-11-08T09:00:00Z"
+// @Param mode          query  string false "after, before or nearest"
+// @Param maxAge        query  string false "ex: 2h"
+// @Router /api/v1/stations/diff [get]
+func (h *Handlers) FindDiff(c *gin.Context) {
+	from, to, err := timeRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !from.Before(to) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	queryFrom, err := snapshotQuery(c, from)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	queryTo := queryFrom
+	queryTo.At = to
+
+	diff, httpCode, err := h.rideindego.Diff(queryFrom, queryTo)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
 // FindSpecifTime godoc
 // @Summary Snapshot of one station at a specific time
 // @Description.markdown stations
 // @Tags API
 // @Produce json
 // @Param Authorization header string true "Bearer secret_token_static"
-// @Param at            query  string true "ex: 2019-09-01T10:00:00Z"
+// @Param at            query  string true  "ex: 2019-09-01T10:00:00Z"
+// @Param mode          query  string false "after, before or nearest"
+// @Param maxAge        query  string false "ex: 2h"
+// @Param kioskStatus   query  string false "ex: FullService"
+// @Param minBikes      query  int    false "ex: 3"
+// @Param minDocks      query  int    false "ex: 3"
+// @Param isVirtual     query  bool   false "ex: false"
+// @Param zip           query  string false "ex: 19103"
+// @Param kioskType     query  int    false "ex: 1"
+// @Param kioskIds      query  string false "ex: 3005,3006,3010"
+// @Param sort          query  string false "ex: -bikesAvailable"
+// @Param limit         query  int    false "ex: 50"
+// @Param offset        query  int    false "ex: 0"
 // @Router /api/v1/stations [get]
 func (h *Handlers) FindSpecifTime(c *gin.Context) {
 	q := c.Query("at")
@@ -190,21 +621,419 @@ func (h *Handlers) FindSpecifTime(c *gin.Context) {
 		return
 	}
 
-	jsonIndego, httpCode, err := h.rideindego.Search(at, "")
+	query, err := snapshotQuery(c, at)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := rideindego.ParseFilter(c.Request.URL.Query())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	jsonIndego, httpCode, err := h.rideindego.Search(query, "", filter)
 	if err != nil {
 		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
 		return
 	}
 
-	jsonWeather, httpCode, err := h.openweather.Search(at)
+	// the weather is selected relative to the snapshot found
+	query.At = jsonIndego.LastUpdated
+	jsonWeather, httpCode, err := h.openweather.Search(query)
 	if err != nil {
 		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"at":       q,
 		"stations": jsonIndego,
 		"weather":  jsonWeather,
+		"page": gin.H{
+			"total":  jsonIndego.Total,
+			"limit":  filter.Limit,
+			"offset": filter.Offset,
+		},
+	}
+	if len(filter.KioskIDs) > 0 {
+		notFound, notMatched, err := h.rideindego.MissingKioskIDs(filter, jsonIndego)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error while read RideIndego data"})
+			return
+		}
+		resp["notFound"] = notFound
+		resp["notMatched"] = notMatched
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// FindRawPayload godoc
+// @Summary Raw upstream payload of a fetch
+// @Description.markdown snapshotsRaw
+// @Tags API
+// @Produce json
+// @Param Authorization header string true "Bearer secret_token_static"
+// @Param fetchId       path   string true "ex: 0b6c9a4e-2f4d-4d8e-9a53-8d1f0c1f6a01"
+// @Router /api/v1/snapshots/{fetchId}/raw [get]
+func (h *Handlers) FindRawPayload(c *gin.Context) {
+	raw, httpCode, err := h.archive.Raw(c.Param("fetchId"))
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("X-Source", raw.Source)
+	c.Header("X-Fetched-At", raw.FetchedAt.UTC().Format(time.RFC3339))
+	c.Data(http.StatusOK, "application/json", raw.Body)
+}
+
+// FindJob godoc
+// @Summary Status of an asynchronous fetch and store
+// @Description.markdown jobs
+// @Tags API
+// @Produce json
+// @Param Authorization header string true "Bearer secret_token_static"
+// @Param jobId         path   string true "ex: 0b6c9a4e-2f4d-4d8e-9a53-8d1f0c1f6a01"
+// @Router /api/v1/jobs/{jobId} [get]
+func (h *Handlers) FindJob(c *gin.Context) {
+	job, httpCode, err := h.jobs.Search(c.Param("jobId"))
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// FindIngestionRuns godoc
+// @Summary History of fetch and store runs
+// @Description.markdown ingestionRuns
+// @Tags API
+// @Produce json
+// @Param Authorization header string true "Bearer secret_token_static"
+// @Param source        query  string false "ex: rideindego"
+// @Param from          query  string false "ex: 2024-11-08T00:00:00Z"
+// @Param to            query  string false "ex: 2024-11-09T00:00:00Z"
+// @Router /api/v1/ingestion-runs [get]
+func (h *Handlers) FindIngestionRuns(c *gin.Context) {
+	to := time.Now().UTC()
+	if q := c.Query("to"); len(q) > 0 {
+		var err error
+		if to, err = time.Parse(time.RFC3339, q); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid timestamp format"})
+			return
+		}
+	}
+
+	from := to.Add(-24 * time.Hour)
+	if q := c.Query("from"); len(q) > 0 {
+		var err error
+		if from, err = time.Parse(time.RFC3339, q); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid timestamp format"})
+			return
+		}
+	}
+
+	runs, httpCode, err := h.ingest.Runs(c.Query("source"), from, to)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": from.UTC(),
+		"to":   to.UTC(),
+		"runs": runs,
 	})
 }
+
+// FindLatestIngestionRuns godoc
+// @Summary Latest fetch and store run of each source
+// @Description.markdown ingestionRunsLatest
+// @Tags API
+// @Produce json
+// @Param Authorization header string true "Bearer secret_token_static"
+// @Router /api/v1/ingestion-runs/latest [get]
+func (h *Handlers) FindLatestIngestionRuns(c *gin.Context) {
+	runs, httpCode, err := h.ingest.LatestRuns()
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// FindUtilization godoc
+// @Summary Stations ranked by time empty, time full or turnover
+// @Description.markdown analyticsUtilization
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param from          query  string true  "ex: 2024-11-01T00:00:00Z"
+// @Param to            query  string true  "ex: 2024-11-08T00:00:00Z"
+// @Param rankBy        query  string false "empty, full or turnover"
+// @Param top           query  int    false "ex: 20"
+// @Param zip           query  string false "ex: 19103"
+// @Param kioskType     query  int    false "ex: 1"
+// @Router /api/v1/analytics/utilization [get]
+func (h *Handlers) FindUtilization(c *gin.Context) {
+	from, to, err := timeRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := database.UtilizationQuery{
+		From:   from,
+		To:     to,
+		Zip:    c.Query("zip"),
+		RankBy: c.Query("rankBy"),
+	}
+	if q := c.Query("kioskType"); len(q) > 0 {
+		kioskType, err := strconv.Atoi(q)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid kioskType format"})
+			return
+		}
+		query.KioskType = &kioskType
+	}
+	if q := c.Query("top"); len(q) > 0 {
+		if query.Top, err = strconv.Atoi(q); err != nil || query.Top <= 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid top format"})
+			return
+		}
+	}
+
+	utilization, httpCode, err := h.analytics.Utilization(query)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, utilization)
+}
+
+// FindWeatherImpact godoc
+// @Summary Availability and turnover by weather
+// @Description.markdown analyticsWeatherImpact
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param kioskId       query  int    false "ex: 3005"
+// @Param from          query  string true  "ex: 2024-11-01T00:00:00Z"
+// @Param to            query  string true  "ex: 2024-11-08T00:00:00Z"
+// @Router /api/v1/analytics/weather-impact [get]
+func (h *Handlers) FindWeatherImpact(c *gin.Context) {
+	from, to, err := timeRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := database.WeatherImpactQuery{From: from, To: to}
+	if q := c.Query("kioskId"); len(q) > 0 {
+		kioskId, err := strconv.Atoi(q)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid kioskId format"})
+			return
+		}
+		query.KioskID = &kioskId
+	}
+
+	impact, httpCode, err := h.analytics.WeatherImpact(query)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, impact)
+}
+
+// FindFlows godoc
+// @Summary Bike departures and arrivals inferred from the snapshots
+// @Description.markdown analyticsFlows
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param from          query  string true  "ex: 2024-11-01T00:00:00Z"
+// @Param to            query  string true  "ex: 2024-11-08T00:00:00Z"
+// @Param kioskId       query  int    false "ex: 3005"
+// @Router /api/v1/analytics/flows [get]
+func (h *Handlers) FindFlows(c *gin.Context) {
+	from, to, err := timeRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := database.FlowQuery{From: from, To: to}
+	if q := c.Query("kioskId"); len(q) > 0 {
+		kioskId, err := strconv.Atoi(q)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid kioskId format"})
+			return
+		}
+		query.KioskID = &kioskId
+	}
+
+	flows, httpCode, err := h.analytics.Flows(query)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, flows)
+}
+
+// FindKioskForecast godoc
+// @Summary Forecast of bikes and docks of one station
+// @Description.markdown stationsKioskForecast
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param kioskId       path   string true  "ex: 3005"
+// @Param at            query  string true  "ex: 2026-11-08T17:00:00Z"
+// @Router /api/v1/stations/{kioskId}/forecast [get]
+func (h *Handlers) FindKioskForecast(c *gin.Context) {
+	kioskId, err := strconv.Atoi(c.Param("kioskId"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid kioskId format"})
+		return
+	}
+
+	q := c.Query("at")
+	if len(q) == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid Request Parameter"})
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, q)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid timestamp format"})
+		return
+	}
+
+	forecast, httpCode, err := h.analytics.Forecast(kioskId, at)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, forecast)
+}
+
+// FindAnomalies godoc
+// @Summary Stuck, offline and unavailable stations
+// @Description.markdown anomalies
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param open          query  bool   false "ex: true"
+// @Param kind          query  string false "stuck, offline or unavailable"
+// @Param kioskId       query  int    false "ex: 3005"
+// @Router /api/v1/anomalies [get]
+func (h *Handlers) FindAnomalies(c *gin.Context) {
+	query := database.AnomalyQuery{Kind: c.Query("kind")}
+	if q := c.Query("open"); len(q) > 0 {
+		open, err := strconv.ParseBool(q)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid open format"})
+			return
+		}
+		query.Open = &open
+	}
+
+	if q := c.Query("kioskId"); len(q) > 0 {
+		kioskId, err := strconv.Atoi(q)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid kioskId format"})
+			return
+		}
+		query.KioskID = &kioskId
+	}
+
+	anomalies, httpCode, err := h.anomalies.List(query)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, anomalies)
+}
+
+// FindBatteries godoc
+// @Summary Battery levels of the electric bikes on one snapshot
+// @Description.markdown analyticsBatteries
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param at            query  string false "ex: 2024-11-08T17:00:00Z"
+// @Param mode          query  string false "after, before or nearest"
+// @Param maxAge        query  string false "ex: 2h"
+// @Param kioskId       query  int    false "ex: 3005"
+// @Router /api/v1/analytics/batteries [get]
+func (h *Handlers) FindBatteries(c *gin.Context) {
+	query, err := latestSnapshotQuery(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var kioskId *int
+	if q := c.Query("kioskId"); len(q) > 0 {
+		id, err := strconv.Atoi(q)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid kioskId format"})
+			return
+		}
+		kioskId = &id
+	}
+
+	batteries, httpCode, err := h.batteries.Snapshot(query, kioskId)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, batteries)
+}
+
+// FindBatteriesTrend godoc
+// @Summary Battery levels of the electric bikes over a time range
+// @Description.markdown analyticsBatteriesTrend
+// @Tags API
+// @Produce json
+// @Param Authorization header string true  "Bearer secret_token_static"
+// @Param from          query  string true  "ex: 2024-11-01T00:00:00Z"
+// @Param to            query  string true  "ex: 2024-11-08T00:00:00Z"
+// @Param kioskId       query  int    false "ex: 3005"
+// @Router /api/v1/analytics/batteries/trend [get]
+func (h *Handlers) FindBatteriesTrend(c *gin.Context) {
+	from, to, err := timeRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var kioskId *int
+	if q := c.Query("kioskId"); len(q) > 0 {
+		id, err := strconv.Atoi(q)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid kioskId format"})
+			return
+		}
+		kioskId = &id
+	}
+
+	batteries, httpCode, err := h.batteries.Trend(from, to, kioskId)
+	if err != nil {
+		c.AbortWithStatusJSON(httpCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, batteries)
+}
@@ -7,8 +7,11 @@ import (
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/arthben/BackendGolang/api-gateway/api/ingest"
 	"github.com/arthben/BackendGolang/api-gateway/internal/config"
 	"github.com/arthben/BackendGolang/api-gateway/internal/database"
 	"github.com/gin-gonic/gin"
@@ -100,6 +103,33 @@ func TestStationsWithKioskId(t *testing.T) {
 			paramKioskId:   "3005",
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name: "Success - Nearest",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramAt:        "2024-12-08T01:00:00Z&mode=nearest",
+			paramKioskId:   "3005",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - Max age exceeded",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramAt:        "2024-12-08T01:00:00Z&mode=before&maxAge=1s",
+			paramKioskId:   "3005",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Fail - Invalid mode",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramAt:        "2024-11-08T01:00:00Z&mode=latest",
+			paramKioskId:   "3005",
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, ts := range scenarios {
@@ -179,6 +209,62 @@ func TestStations(t *testing.T) {
 			paramAt:        "2024-12-08T01:00:00Z",
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name: "Success - Before",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramAt:        "2024-12-08T01:00:00Z&mode=before",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - Invalid maxAge",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramAt:        "2024-11-08T01:00:00Z&maxAge=3days",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success - Filter and page",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramAt:        "2024-11-08T01:00:00Z&minBikes=3&isVirtual=false&sort=-bikesAvailable&limit=10&offset=10",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - Invalid sort",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramAt:        "2024-11-08T01:00:00Z&sort=name",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Invalid limit",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramAt:        "2024-11-08T01:00:00Z&limit=1000",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success - KioskIds",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramAt:        "2024-11-08T01:00:00Z&kioskIds=3005,3006,1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - Invalid kioskIds",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramAt:        "2024-11-08T01:00:00Z&kioskIds=3005,abc",
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, ts := range scenarios {
@@ -269,18 +355,1315 @@ func TestFetchAndStore(t *testing.T) {
 			}
 
 			if ts.expectedStatus == http.StatusOK {
-				var res map[string]string
+				var res struct {
+					Status     string              `json:"status"`
+					RideIndego ingest.SourceResult `json:"rideindego"`
+				}
 				err = json.Unmarshal(w.Body.Bytes(), &res)
 				if err != nil {
 					t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
 					return
 				}
 
-				if res["status"] != "Fetch and store success" {
-					t.Errorf("Expected status %d but response body mismatch - %v", ts.expectedStatus, res["status"])
+				if res.Status != "Fetch and store success" {
+					t.Errorf("Expected status %d but response body mismatch - %v", ts.expectedStatus, res.Status)
+					return
+				}
+
+				if res.RideIndego.HTTPCode != http.StatusOK {
+					t.Errorf("Expected rideindego status %d but got %d", http.StatusOK, res.RideIndego.HTTPCode)
 					return
 				}
+			}
+		})
+	}
+}
+
+func TestSnapshotsRaw(t *testing.T) {
+	ro := setupRouter()
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		paramFetchId   string
+		expectedStatus int
+	}{
+		{
+			name: "Fail - FetchId not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramFetchId:   "3005",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramFetchId:   "00000000-0000-0000-0000-000000000000",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			paramFetchId:   "00000000-0000-0000-0000-000000000000",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/snapshots/"+ts.paramFetchId+"/raw", nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestJobs(t *testing.T) {
+	ro := setupRouter()
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		paramJobId     string
+		expectedStatus int
+	}{
+		{
+			name: "Fail - JobId not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramJobId:     "3005",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramJobId:     "00000000-0000-0000-0000-000000000000",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			paramJobId:     "00000000-0000-0000-0000-000000000000",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/jobs/"+ts.paramJobId, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestIngestionRuns(t *testing.T) {
+	ro := setupRouter()
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "Success - Default range",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - Invalid source",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?source=citibike",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Invalid timestamp",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=2024-11-08",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			query:          "",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
 
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/ingestion-runs"+ts.query, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestStationHistory(t *testing.T) {
+	ro := setupRouter()
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		paramKioskId   string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "Success - Downsampled",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramKioskId:   "3005",
+			query:          "?from=2000-01-01T00:00:00Z&to=2100-01-01T00:00:00Z&interval=24h",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - KioskId not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramKioskId:   "abc",
+			query:          "?from=2024-11-01T00:00:00Z&to=2024-11-02T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Missing to",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramKioskId:   "3005",
+			query:          "?from=2024-11-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Interval not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramKioskId:   "3005",
+			query:          "?from=2024-11-01T00:00:00Z&to=2024-11-02T00:00:00Z&interval=1day",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Too many points",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramKioskId:   "3005",
+			query:          "?from=2000-01-01T00:00:00Z&to=2024-11-02T00:00:00Z&interval=1m",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramKioskId:   "1",
+			query:          "?from=2024-11-01T00:00:00Z&to=2024-11-02T00:00:00Z",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			paramKioskId:   "3005",
+			query:          "?from=2024-11-01T00:00:00Z&to=2024-11-02T00:00:00Z",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/stations/"+ts.paramKioskId+"/history"+ts.query, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestStationsNearby(t *testing.T) {
+	ro := setupRouter()
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "Success - Latest snapshot",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?lat=39.9526&lon=-75.1652&radius=1000&minBikes=1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Success - At specific time",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?lat=39.9526&lon=-75.1652&at=2024-11-08T01:00:00Z&limit=5&offset=5&zip=19106",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - Missing lon",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?lat=39.9526",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Lat is NaN",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?lat=NaN&lon=-75.1652",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Radius is Inf",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?lat=39.9526&lon=-75.1652&radius=Inf",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Radius too large",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?lat=39.9526&lon=-75.1652&radius=50000",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Sort not supported",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?lat=39.9526&lon=-75.1652&sort=-bikesAvailable",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?lat=39.9526&lon=-75.1652&at=2124-11-08T01:00:00Z",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			query:          "?lat=39.9526&lon=-75.1652",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/stations/nearby"+ts.query, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestStationsWithin(t *testing.T) {
+	ro := setupRouter()
+
+	polygon := `{"type":"Polygon","coordinates":[[[-75.18,39.94],[-75.14,39.94],[-75.14,39.96],[-75.18,39.96],[-75.18,39.94]]]}`
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		method         string
+		query          string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "Success - BBox",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			method:         "GET",
+			query:          "?bbox=-75.18,39.94,-75.14,39.96&at=2024-11-08T01:00:00Z",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - Invalid bbox",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			method:         "GET",
+			query:          "?bbox=-75.18,39.94",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success - Polygon",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			method:         "POST",
+			query:          "?at=2024-11-08T01:00:00Z",
+			body:           polygon,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - Not a polygon",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			method:         "POST",
+			body:           `{"type":"Point","coordinates":[-75.18,39.94]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			method:         "POST",
+			body:           polygon,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest(ts.method, "/api/v1/stations/within"+ts.query, strings.NewReader(ts.body))
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestStationsLatest(t *testing.T) {
+	ro := setupRouter()
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name: "Success - All stations",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			path:           "/api/v1/stations/latest",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Success - One station",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			path:           "/api/v1/stations/3005/latest",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - KioskId not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			path:           "/api/v1/stations/abc/latest",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			path:           "/api/v1/stations/latest",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", ts.path, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestStationsDiff(t *testing.T) {
+	ro := setupRouter()
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=2000-01-01T00:00:00Z&to=2100-01-01T00:00:00Z&mode=nearest",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - Missing to",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=2024-11-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Timestamp not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=2024-11-01&to=2024-11-02T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - From after to",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=2024-11-02T00:00:00Z&to=2024-11-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Mode not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=2024-11-01T00:00:00Z&to=2024-11-02T00:00:00Z&mode=closest",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=1990-01-01T00:00:00Z&to=1990-01-02T00:00:00Z&mode=after&maxAge=1h",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			query:          "?from=2024-11-01T00:00:00Z&to=2024-11-02T00:00:00Z",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/stations/diff"+ts.query, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestStationsStats(t *testing.T) {
+	ro := setupRouter()
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name: "Success - One station",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			path:           "/api/v1/stations/3005/stats?granularity=day&from=2000-01-01T00:00:00Z&to=2100-01-01T00:00:00Z",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Success - All stations",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			path:           "/api/v1/stations/stats?from=2000-01-01T00:00:00Z&to=2100-01-01T00:00:00Z",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - KioskId not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			path:           "/api/v1/stations/abc/stats?from=2024-11-01T00:00:00Z&to=2024-11-02T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Granularity not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			path:           "/api/v1/stations/stats?granularity=week&from=2024-11-01T00:00:00Z&to=2024-11-02T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Missing from",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			path:           "/api/v1/stations/3005/stats?to=2024-11-02T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			path:           "/api/v1/stations/1/stats?from=2024-11-01T00:00:00Z&to=2024-11-02T00:00:00Z",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			path:           "/api/v1/stations/stats?from=2024-11-01T00:00:00Z&to=2024-11-02T00:00:00Z",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", ts.path, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestUtilization(t *testing.T) {
+	ro := setupRouter()
+
+	// the stored snapshots are recent, rank the last 30 days
+	now := time.Now().UTC()
+	lastMonth := fmt.Sprintf("?from=%s&to=%s", now.AddDate(0, 0, -30).Format(time.RFC3339), now.Format(time.RFC3339))
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth + "&rankBy=turnover&top=10",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - RankBy not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth + "&rankBy=busy",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Top not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth + "&top=ten",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - KioskType not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth + "&kioskType=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Range too long",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=2024-01-01T00:00:00Z&to=2024-11-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=1990-01-01T00:00:00Z&to=1990-01-02T00:00:00Z",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			query:          lastMonth,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/analytics/utilization"+ts.query, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestWeatherImpact(t *testing.T) {
+	ro := setupRouter()
+
+	// the stored snapshots are recent, use the last 30 days
+	now := time.Now().UTC()
+	lastMonth := fmt.Sprintf("?from=%s&to=%s", now.AddDate(0, 0, -30).Format(time.RFC3339), now.Format(time.RFC3339))
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "Success - All stations",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Success - One station",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth + "&kioskId=3005",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - KioskId not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth + "&kioskId=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Range too long",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=2024-01-01T00:00:00Z&to=2024-11-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=1990-01-01T00:00:00Z&to=1990-01-02T00:00:00Z",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			query:          lastMonth,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/analytics/weather-impact"+ts.query, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestFlows(t *testing.T) {
+	ro := setupRouter()
+
+	// the stored snapshots are recent, use the last 30 days
+	now := time.Now().UTC()
+	lastMonth := fmt.Sprintf("?from=%s&to=%s", now.AddDate(0, 0, -30).Format(time.RFC3339), now.Format(time.RFC3339))
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "Success - All stations",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Success - One station",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth + "&kioskId=3005",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - KioskId not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth + "&kioskId=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Range too long",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=2024-01-01T00:00:00Z&to=2024-11-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=1990-01-01T00:00:00Z&to=1990-01-02T00:00:00Z",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			query:          lastMonth,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/analytics/flows"+ts.query, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestStationForecast(t *testing.T) {
+	ro := setupRouter()
+
+	nextHour := time.Now().UTC().Add(time.Hour).Format(time.RFC3339)
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		paramKioskId   string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramKioskId:   "3005",
+			query:          "?at=" + time.Now().UTC().AddDate(0, 0, 7).Format(time.RFC3339),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - KioskId not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramKioskId:   "abc",
+			query:          "?at=" + nextHour,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Missing at",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramKioskId:   "3005",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - At in the past",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramKioskId:   "3005",
+			query:          "?at=2024-11-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			paramKioskId:   "1",
+			query:          "?at=" + nextHour,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			paramKioskId:   "3005",
+			query:          "?at=" + nextHour,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/stations/"+ts.paramKioskId+"/forecast"+ts.query, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestAnomalies(t *testing.T) {
+	ro := setupRouter()
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "Success - Open anomalies",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?open=true",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Success - Stuck anomalies of one station",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?kind=stuck&kioskId=3005",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - Open not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?open=maybe",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Kind not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?kind=broken",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - KioskId not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?kioskId=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			query:          "?open=true",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/anomalies"+ts.query, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestBatteries(t *testing.T) {
+	ro := setupRouter()
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "Success - Latest snapshot",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Success - One station before a time",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?at=2024-11-08T17:00:00Z&mode=before&kioskId=3005",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - Timestamp not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?at=2024-11-08",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - KioskId not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?kioskId=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?at=1990-01-01T00:00:00Z&mode=before",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			query:          "",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/analytics/batteries"+ts.query, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
+			}
+		})
+	}
+}
+
+func TestBatteriesTrend(t *testing.T) {
+	ro := setupRouter()
+
+	// the stored snapshots are recent, use the last 30 days
+	now := time.Now().UTC()
+	lastMonth := fmt.Sprintf("?from=%s&to=%s", now.AddDate(0, 0, -30).Format(time.RFC3339), now.Format(time.RFC3339))
+
+	scenarios := []struct {
+		name           string
+		header         map[string]string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "Success - All stations",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Success - One station",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth + "&kioskId=3005",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Fail - KioskId not valid",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          lastMonth + "&kioskId=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Range too long",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=2024-01-01T00:00:00Z&to=2024-11-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - No Data Found",
+			header: map[string]string{
+				"Authorization": "Bearer secret_token_static",
+			},
+			query:          "?from=1990-01-01T00:00:00Z&to=1990-01-02T00:00:00Z",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - Without Token",
+			header:         map[string]string{},
+			query:          lastMonth,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/analytics/batteries/trend"+ts.query, nil)
+			if err != nil {
+				t.Errorf("Expected status %d but error occured - %v", ts.expectedStatus, err.Error())
+				return
+			}
+
+			for key, v := range ts.header {
+				req.Header.Add(key, v)
+			}
+
+			w := httptest.NewRecorder()
+			ro.ServeHTTP(w, req)
+
+			if w.Code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got response %d", ts.expectedStatus, w.Code)
+				return
 			}
 		})
 	}
@@ -0,0 +1,295 @@
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/api/analytics"
+	"github.com/arthben/BackendGolang/api-gateway/api/anomalies"
+	"github.com/arthben/BackendGolang/api-gateway/api/latest"
+	"github.com/arthben/BackendGolang/api-gateway/api/openweather"
+	"github.com/arthben/BackendGolang/api-gateway/api/rideindego"
+	"github.com/arthben/BackendGolang/api-gateway/api/stats"
+	"github.com/arthben/BackendGolang/api-gateway/internal/config"
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const StatusFailed = "failed"
+
+// weatherLinkMaxAge is the oldest Indego snapshot a weather fetched alone
+// is linked to
+const weatherLinkMaxAge = time.Hour
+
+// SourceResult is the outcome of fetch and store of one upstream.
+// RawFetchID is fetch_id of the archived upstream body, empty when the
+// fetch failed
+type SourceResult struct {
+	Status     string `json:"status"`
+	HTTPCode   int    `json:"httpCode"`
+	Rows       int    `json:"rows"`
+	DurationMs int64  `json:"durationMs"`
+	RawFetchID string `json:"rawFetchId,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type Result struct {
+	RideIndego  SourceResult `json:"rideindego"`
+	OpenWeather SourceResult `json:"openweather"`
+}
+
+// HTTPStatus returns 200 when all sources succeed, 207 when only some of
+// them failed and 502 when all of them failed
+func (r Result) HTTPStatus() int {
+	failed := 0
+	for _, source := range []SourceResult{r.RideIndego, r.OpenWeather} {
+		if source.Status == StatusFailed {
+			failed++
+		}
+	}
+
+	switch failed {
+	case 0:
+		return http.StatusOK
+	case 2:
+		return http.StatusBadGateway
+	default:
+		return http.StatusMultiStatus
+	}
+}
+
+// JobStatus returns the status of a job with the result, partial when only
+// some of the sources failed
+func (r Result) JobStatus() string {
+	switch r.HTTPStatus() {
+	case http.StatusOK:
+		return dbase.JobSucceeded
+	case http.StatusMultiStatus:
+		return dbase.JobPartial
+	default:
+		return dbase.JobFailed
+	}
+}
+
+func (r Result) Message() string {
+	switch r.HTTPStatus() {
+	case http.StatusOK:
+		return "Fetch and store success"
+	case http.StatusMultiStatus:
+		return "Fetch and store partial success"
+	default:
+		return "Fetch and store failed"
+	}
+}
+
+// Service fetch and store the upstreams. Runs never overlap, whether they
+// come from the scheduler, a job or a synchronous request, as long as they
+// share the same Service
+type Service struct {
+	mu          sync.Mutex
+	db          dbase.DBService
+	rideindego  *rideindego.Service
+	openweather *openweather.Service
+	latest      *latest.Cache
+	stats       *stats.Service
+	analytics   *analytics.Service
+	anomalies   *anomalies.Service
+}
+
+// NewService refresh cache after each stored Indego snapshot, nil cache
+// means no refresh
+func NewService(db dbase.DBService, cfg *config.EnvParams, cache *latest.Cache) *Service {
+	return &Service{
+		db:          db,
+		rideindego:  rideindego.NewService(db),
+		openweather: openweather.NewService(db, cfg),
+		latest:      cache,
+		stats:       stats.NewService(db),
+		analytics:   analytics.NewService(db),
+		anomalies:   anomalies.NewService(db, cfg),
+	}
+}
+
+// FetchAndStore fetch both upstreams in parallel. The weather is linked
+// to the Indego snapshot of the same run
+func (s *Service) FetchAndStore() Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		chIndego   = make(chan SourceResult, 1)
+		chSnapshot = make(chan string, 1)
+	)
+
+	go func() {
+		chIndego <- s.fetchIndego(chSnapshot)
+	}()
+
+	return Result{
+		OpenWeather: s.fetchWeather(chSnapshot),
+		RideIndego:  <-chIndego,
+	}
+}
+
+// FetchAndStoreIndego fetch and store Indego only
+func (s *Service) FetchAndStoreIndego() SourceResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.fetchIndego(make(chan string, 1))
+}
+
+// FetchAndStoreWeather fetch and store the weather, linked to the newest
+// Indego snapshot of the last hour when it has no weather yet
+func (s *Service) FetchAndStoreWeather() SourceResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chSnapshot := make(chan string, 1)
+	chSnapshot <- s.latestSnapshotID()
+	return s.fetchWeather(chSnapshot)
+}
+
+// latestSnapshotID returns fetch_id of the newest Indego snapshot taken
+// within weatherLinkMaxAge, empty when there is none
+func (s *Service) latestSnapshotID() string {
+	q := dbase.SnapshotQuery{At: time.Now().UTC(), Mode: dbase.ModeBefore, MaxAge: weatherLinkMaxAge}
+	master, err := s.db.SearchRideIndegoMaster(context.Background(), q)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Msg("Service -> SearchRideIndegoMaster")
+		}
+		return ""
+	}
+	return master.FetchID
+}
+
+func (s *Service) fetchIndego(chSnapshot chan<- string) SourceResult {
+	run := newRun(dbase.RawSourceRideIndego)
+
+	rawData, httpCode, err := s.rideindego.Fetch()
+	run.UpstreamStatus = httpCode
+	if err != nil {
+		chSnapshot <- ""
+		return s.record(run, "", err)
+	}
+
+	result, err := s.rideindego.Store(rawData)
+
+	// an unchanged snapshot already has the weather of its own run
+	if result.Status == rideindego.StatusStored {
+		chSnapshot <- result.FetchID
+	} else {
+		chSnapshot <- ""
+	}
+
+	run.FetchID = result.FetchID
+	run.Features = result.Features
+	run.Properties = result.Properties
+	run.Bikes = result.Bikes
+	run.TotalRows = result.Rows()
+
+	if result.Status == rideindego.StatusStored {
+		s.refreshDerived(result.LastUpdate)
+	}
+
+	source := s.record(run, result.Status, err)
+	source.RawFetchID = result.RawFetchID
+	return source
+}
+
+func (s *Service) fetchWeather(chSnapshot <-chan string) SourceResult {
+	run := newRun(dbase.RawSourceOpenWeather)
+
+	rawData, httpCode, err := s.openweather.Fetch()
+	run.UpstreamStatus = httpCode
+	if err != nil {
+		return s.record(run, "", err)
+	}
+
+	// link the weather to Indego snapshot fetched on the same run
+	result, err := s.openweather.Store(rawData, <-chSnapshot)
+
+	run.FetchID = result.FetchID
+	run.TotalRows = result.Rows()
+
+	source := s.record(run, result.Status, err)
+	source.RawFetchID = result.RawFetchID
+	return source
+}
+
+// refreshDerived refresh the rollups and flows of the hour of the new
+// snapshot and check the stations for anomalies. A snapshot stored out of
+// order also changes the flows of the snapshots up to FlowMaxGap after it.
+// They are derived data, failing to refresh them does not fail the run
+func (s *Service) refreshDerived(at time.Time) {
+	if s.stats != nil {
+		if err := s.stats.Refresh(at, at); err != nil {
+			log.Error().Err(err).Msg("Service -> stats.Refresh")
+		}
+	}
+
+	if s.analytics != nil {
+		if err := s.analytics.RefreshFlows(at, at.Add(dbase.FlowMaxGap)); err != nil {
+			log.Error().Err(err).Msg("Service -> analytics.RefreshFlows")
+		}
+	}
+
+	if s.anomalies != nil {
+		if err := s.anomalies.Detect(at); err != nil {
+			log.Error().Err(err).Msg("Service -> anomalies.Detect")
+		}
+	}
+}
+
+func newRun(source string) *dbase.IngestionRun {
+	return &dbase.IngestionRun{
+		RunID:     uuid.NewString(),
+		Source:    source,
+		StartedAt: time.Now().UTC(),
+	}
+}
+
+// record finish the run and store it to ingestion_runs. Failing to store
+// the run is only logged, it does not change the result
+func (s *Service) record(run *dbase.IngestionRun, status string, err error) SourceResult {
+	run.FinishedAt = time.Now().UTC()
+	run.Outcome = status
+
+	result := SourceResult{
+		Status:     status,
+		HTTPCode:   run.UpstreamStatus,
+		Rows:       run.TotalRows,
+		DurationMs: run.FinishedAt.Sub(run.StartedAt).Milliseconds(),
+	}
+
+	// HTTPCode stays the upstream code, a failed store is reported by the
+	// status and the error only
+	if err != nil {
+		run.Outcome = StatusFailed
+		run.Features, run.Properties, run.Bikes, run.TotalRows = 0, 0, 0, 0
+		run.Error = err.Error()
+
+		result.Status = StatusFailed
+		result.Rows = 0
+		result.Error = err.Error()
+	}
+
+	if err := s.db.StoreIngestionRun(context.Background(), run); err != nil {
+		log.Error().Err(err).Str("source", run.Source).Msg("Service -> StoreIngestionRun")
+	}
+
+	// new data, refresh the latest snapshot served from memory
+	if run.Outcome == rideindego.StatusStored && s.latest != nil {
+		if err := s.latest.Refresh(); err != nil {
+			log.Error().Err(err).Msg("Service -> latest.Refresh")
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,56 @@
+package ingest
+
+import (
+	"net/http"
+	"testing"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+func TestResultHTTPStatus(t *testing.T) {
+	stored := SourceResult{Status: "stored", HTTPCode: http.StatusOK}
+	failed := SourceResult{Status: StatusFailed, HTTPCode: http.StatusBadGateway}
+
+	scenarios := []struct {
+		name           string
+		result         Result
+		expectedStatus int
+		expectedJob    string
+	}{
+		{
+			name:           "All success",
+			result:         Result{RideIndego: stored, OpenWeather: stored},
+			expectedStatus: http.StatusOK,
+			expectedJob:    dbase.JobSucceeded,
+		},
+		{
+			name:           "Weather failed",
+			result:         Result{RideIndego: stored, OpenWeather: failed},
+			expectedStatus: http.StatusMultiStatus,
+			expectedJob:    dbase.JobPartial,
+		},
+		{
+			name:           "Indego failed",
+			result:         Result{RideIndego: failed, OpenWeather: stored},
+			expectedStatus: http.StatusMultiStatus,
+			expectedJob:    dbase.JobPartial,
+		},
+		{
+			name:           "All failed",
+			result:         Result{RideIndego: failed, OpenWeather: failed},
+			expectedStatus: http.StatusBadGateway,
+			expectedJob:    dbase.JobFailed,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			if code := ts.result.HTTPStatus(); code != ts.expectedStatus {
+				t.Errorf("Expected status %d but got %d", ts.expectedStatus, code)
+			}
+			if status := ts.result.JobStatus(); status != ts.expectedJob {
+				t.Errorf("Expected job status %s but got %s", ts.expectedJob, status)
+			}
+		})
+	}
+}
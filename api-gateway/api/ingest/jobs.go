@@ -0,0 +1,165 @@
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const maxQueuedJobs = 16
+
+// Job is the status of an asynchronous fetch and store. Source results are
+// filled when the job finished
+type Job struct {
+	JobID       string        `json:"jobId"`
+	Status      string        `json:"status"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	StartedAt   *time.Time    `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time    `json:"finishedAt,omitempty"`
+	RideIndego  *SourceResult `json:"rideindego,omitempty"`
+	OpenWeather *SourceResult `json:"openweather,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// Jobs run the submitted jobs one by one on a single worker. Jobs are
+// persisted, the queued ones are picked up again after restart
+type Jobs struct {
+	db       dbase.DBService
+	ingest   *Service
+	queue    chan string
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewJobs recover the jobs of the previous run before it returns, so a job
+// submitted after is never recovered and run twice
+func NewJobs(db dbase.DBService, ingest *Service) *Jobs {
+	j := &Jobs{
+		db:     db,
+		ingest: ingest,
+		queue:  make(chan string, maxQueuedJobs),
+		stop:   make(chan struct{}),
+	}
+
+	recovered, err := db.RecoverIngestionJobs(context.Background())
+	if err != nil {
+		log.Error().Err(err).Msg("Jobs -> RecoverIngestionJobs")
+	}
+
+	j.wg.Add(1)
+	go j.worker(recovered)
+	return j
+}
+
+// Stop wait for the running job and stop the worker. Jobs still queued stay
+// queued and are run after restart
+func (j *Jobs) Stop() {
+	j.stopOnce.Do(func() { close(j.stop) })
+	j.wg.Wait()
+}
+
+func (j *Jobs) Submit() (*Job, int, error) {
+	job := &dbase.IngestionJob{
+		JobID:     uuid.NewString(),
+		Status:    dbase.JobQueued,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := j.db.StoreIngestionJob(context.Background(), job); err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while store job")
+	}
+
+	select {
+	case j.queue <- job.JobID:
+	default:
+		job.Status = dbase.JobFailed
+		job.Result = `{"error": "too many queued jobs"}`
+		_ = j.db.UpdateIngestionJob(context.Background(), job)
+		return nil, http.StatusServiceUnavailable, errors.New("Too many queued jobs")
+	}
+
+	return &Job{JobID: job.JobID, Status: job.Status, CreatedAt: job.CreatedAt}, http.StatusAccepted, nil
+}
+
+func (j *Jobs) Search(jobID string) (*Job, int, error) {
+	if _, err := uuid.Parse(jobID); err != nil {
+		return nil, http.StatusBadRequest, errors.New("Invalid jobId format")
+	}
+
+	row, err := j.db.SearchIngestionJob(context.Background(), jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, http.StatusNotFound, errors.New("Job not found")
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read job")
+	}
+
+	job := Job{
+		JobID:      row.JobID,
+		Status:     row.Status,
+		CreatedAt:  row.CreatedAt.UTC(),
+		StartedAt:  row.StartedAt,
+		FinishedAt: row.FinishedAt,
+	}
+	if len(row.Result) > 0 {
+		if err := json.Unmarshal([]byte(row.Result), &job); err != nil {
+			return nil, http.StatusInternalServerError, errors.New("Error while read job")
+		}
+	}
+
+	return &job, http.StatusOK, nil
+}
+
+// worker run the recovered jobs, oldest first, then the submitted ones
+func (j *Jobs) worker(recovered []string) {
+	defer j.wg.Done()
+
+	for _, jobID := range recovered {
+		select {
+		case <-j.stop:
+			return
+		default:
+		}
+		j.run(jobID)
+	}
+
+	for {
+		select {
+		case <-j.stop:
+			return
+		case jobID := <-j.queue:
+			j.run(jobID)
+		}
+	}
+}
+
+func (j *Jobs) run(jobID string) {
+	ctx := context.Background()
+
+	started := time.Now().UTC()
+	job := &dbase.IngestionJob{JobID: jobID, Status: dbase.JobRunning, StartedAt: &started}
+	if err := j.db.UpdateIngestionJob(ctx, job); err != nil {
+		log.Error().Err(err).Str("jobId", jobID).Msg("Jobs -> UpdateIngestionJob")
+	}
+
+	result := j.ingest.FetchAndStore()
+
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	job.Status = result.JobStatus()
+
+	raw, _ := json.Marshal(result)
+	job.Result = string(raw)
+	if err := j.db.UpdateIngestionJob(ctx, job); err != nil {
+		log.Error().Err(err).Str("jobId", jobID).Msg("Jobs -> UpdateIngestionJob")
+	}
+}
@@ -0,0 +1,116 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/api/openweather"
+	"github.com/arthben/BackendGolang/api-gateway/api/rideindego"
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+// Run is one fetch and store of one source, read from ingestion_runs
+type Run struct {
+	RunID          string    `json:"runId"`
+	Source         string    `json:"source"`
+	StartedAt      time.Time `json:"startedAt"`
+	FinishedAt     time.Time `json:"finishedAt"`
+	DurationMs     int64     `json:"durationMs"`
+	Outcome        string    `json:"outcome"`
+	UpstreamStatus int       `json:"upstreamStatus"`
+	FetchID        string    `json:"fetchId,omitempty"`
+	Features       int       `json:"features"`
+	Properties     int       `json:"properties"`
+	Bikes          int       `json:"bikes"`
+	Rows           int       `json:"rows"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// LatestRun is the last run of a source and the last one which succeed
+type LatestRun struct {
+	Latest      *Run `json:"latest"`
+	LastSuccess *Run `json:"lastSuccess"`
+}
+
+var runSources = map[string]bool{
+	"":                         true,
+	dbase.RawSourceRideIndego:  true,
+	dbase.RawSourceOpenWeather: true,
+}
+
+// Runs returns the runs started between from and to, newest first. Empty
+// source means all sources
+func (s *Service) Runs(source string, from time.Time, to time.Time) ([]Run, int, error) {
+	if !runSources[source] {
+		return nil, http.StatusBadRequest, errors.New("Invalid source")
+	}
+	if from.After(to) {
+		return nil, http.StatusBadRequest, errors.New("from must be before to")
+	}
+
+	rows, err := s.db.SearchIngestionRuns(context.Background(), source, from, to)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read ingestion runs")
+	}
+
+	runs := make([]Run, 0, len(rows))
+	for _, row := range rows {
+		runs = append(runs, toRun(row))
+	}
+
+	return runs, http.StatusOK, nil
+}
+
+// LatestRuns returns the latest run and the latest successful run of each
+// source which has run at least once
+func (s *Service) LatestRuns() (map[string]LatestRun, int, error) {
+	ctx := context.Background()
+
+	latest, err := s.db.LatestIngestionRuns(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read ingestion runs")
+	}
+	if len(latest) == 0 {
+		return nil, http.StatusNotFound, errors.New("Data Not Found")
+	}
+
+	success, err := s.db.LatestIngestionRuns(ctx, rideindego.StatusStored, rideindego.StatusUnchanged,
+		openweather.StatusStored, openweather.StatusUnchanged)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read ingestion runs")
+	}
+
+	result := make(map[string]LatestRun, len(latest))
+	for _, row := range latest {
+		run := toRun(row)
+		result[row.Source] = LatestRun{Latest: &run}
+	}
+	for _, row := range success {
+		run := toRun(row)
+		entry := result[row.Source]
+		entry.LastSuccess = &run
+		result[row.Source] = entry
+	}
+
+	return result, http.StatusOK, nil
+}
+
+func toRun(row *dbase.IngestionRun) Run {
+	return Run{
+		RunID:          row.RunID,
+		Source:         row.Source,
+		StartedAt:      row.StartedAt.UTC(),
+		FinishedAt:     row.FinishedAt.UTC(),
+		DurationMs:     row.FinishedAt.Sub(row.StartedAt).Milliseconds(),
+		Outcome:        row.Outcome,
+		UpstreamStatus: row.UpstreamStatus,
+		FetchID:        row.FetchID,
+		Features:       row.Features,
+		Properties:     row.Properties,
+		Bikes:          row.Bikes,
+		Rows:           row.TotalRows,
+		Error:          row.Error,
+	}
+}
@@ -0,0 +1,86 @@
+package ingest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/arthben/BackendGolang/api-gateway/internal/database/dbtest"
+)
+
+func TestRecordRun(t *testing.T) {
+	scenarios := []struct {
+		name             string
+		upstreamStatus   int
+		status           string
+		err              error
+		expectedOutcome  string
+		expectedHTTPCode int
+		expectedRows     int
+	}{
+		{
+			name:             "Stored",
+			upstreamStatus:   http.StatusOK,
+			status:           "stored",
+			expectedOutcome:  "stored",
+			expectedHTTPCode: http.StatusOK,
+			expectedRows:     10,
+		},
+		{
+			name:             "Upstream failed",
+			upstreamStatus:   http.StatusServiceUnavailable,
+			err:              errors.New("Unexpected status code: 503"),
+			expectedOutcome:  StatusFailed,
+			expectedHTTPCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:             "Store failed",
+			upstreamStatus:   http.StatusOK,
+			err:              errors.New("Error while store RideIndego data"),
+			expectedOutcome:  StatusFailed,
+			expectedHTTPCode: http.StatusOK,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			db := &dbtest.FakeDB{}
+			s := &Service{db: db}
+
+			run := newRun(dbase.RawSourceRideIndego)
+			run.UpstreamStatus = ts.upstreamStatus
+			run.TotalRows = 10
+			result := s.record(run, ts.status, ts.err)
+
+			if len(db.Runs) != 1 {
+				t.Fatalf("Expected 1 stored run but got %d", len(db.Runs))
+			}
+			if stored := db.Runs[0]; stored.Outcome != ts.expectedOutcome || stored.TotalRows != ts.expectedRows {
+				t.Errorf("Expected outcome %s with %d rows but got %s with %d rows",
+					ts.expectedOutcome, ts.expectedRows, stored.Outcome, stored.TotalRows)
+			}
+			if db.Runs[0].UpstreamStatus != result.HTTPCode {
+				t.Errorf("Expected upstream status %d but got %d", result.HTTPCode, db.Runs[0].UpstreamStatus)
+			}
+			if result.HTTPCode != ts.expectedHTTPCode || result.Rows != ts.expectedRows {
+				t.Errorf("Expected status %d with %d rows but got %d with %d rows",
+					ts.expectedHTTPCode, ts.expectedRows, result.HTTPCode, result.Rows)
+			}
+		})
+	}
+}
+
+func TestRunsInvalidParams(t *testing.T) {
+	s := &Service{db: &dbtest.FakeDB{}}
+	now := time.Now()
+
+	if _, code, _ := s.Runs("citibike", now.Add(-time.Hour), now); code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for unknown source but got %d", http.StatusBadRequest, code)
+	}
+
+	if _, code, _ := s.Runs("", now, now.Add(-time.Hour)); code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for from after to but got %d", http.StatusBadRequest, code)
+	}
+}
@@ -0,0 +1,173 @@
+package latest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/api/openweather"
+	"github.com/arthben/BackendGolang/api-gateway/api/rideindego"
+	"github.com/arthben/BackendGolang/api-gateway/internal/config"
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+const (
+	// defaultMaxAge is the staleness threshold when the scheduler is disabled
+	defaultMaxAge = time.Hour
+	// reloadInterval limit the reload of a stale snapshot from database,
+	// in case it was stored by another process
+	reloadInterval = time.Minute
+)
+
+// Snapshot is the most recent snapshot of the stations with its weather
+type Snapshot struct {
+	Stations *rideindego.FetchResponse
+	Weather  *openweather.FetchResponse
+}
+
+// Latest is the response of the latest endpoints. Age is in seconds since
+// last_updated of the snapshot, Stale is true when the age is longer than
+// the ingest interval
+type Latest struct {
+	At       time.Time                  `json:"at"`
+	Age      int64                      `json:"age"`
+	Stale    bool                       `json:"stale"`
+	Stations *rideindego.FetchResponse  `json:"stations"`
+	Weather  *openweather.FetchResponse `json:"weather"`
+}
+
+// Cache keep the most recent snapshot in memory. Ingestion refresh it
+// after storing a new snapshot, it is loaded from database on first use
+type Cache struct {
+	rideindego  *rideindego.Service
+	openweather *openweather.Service
+	maxAge      time.Duration
+
+	mu       sync.RWMutex
+	snapshot *Snapshot
+	loadedAt time.Time
+}
+
+func NewCache(db dbase.DBService, cfg *config.EnvParams) *Cache {
+	return &Cache{
+		rideindego:  rideindego.NewService(db),
+		openweather: openweather.NewService(db, cfg),
+		maxAge:      maxAge(cfg),
+	}
+}
+
+// maxAge is the Indego ingest interval including the jitter
+func maxAge(cfg *config.EnvParams) time.Duration {
+	if cfg.Scheduler.IndegoInterval <= 0 {
+		return defaultMaxAge
+	}
+	return time.Duration(cfg.Scheduler.IndegoInterval+cfg.Scheduler.Jitter) * time.Second
+}
+
+// Refresh load the most recent snapshot from database
+func (c *Cache) Refresh() error {
+	query := dbase.SnapshotQuery{At: time.Now().UTC(), Mode: dbase.ModeBefore}
+	stations, _, err := c.rideindego.Search(query, "", dbase.PropertiesFilter{})
+	if err != nil {
+		c.markLoaded()
+		return err
+	}
+
+	query.At = stations.LastUpdated
+	weather, _, err := c.openweather.Search(query)
+	if err != nil {
+		weather = nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.snapshot = &Snapshot{Stations: stations, Weather: weather}
+	c.loadedAt = time.Now()
+	return nil
+}
+
+func (c *Cache) markLoaded() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.loadedAt = time.Now()
+}
+
+// Get returns the cached snapshot. It is loaded when empty, and reloaded
+// at most once a minute while stale
+func (c *Cache) Get() (*Snapshot, error) {
+	c.mu.RLock()
+	snapshot, loadedAt := c.snapshot, c.loadedAt
+	c.mu.RUnlock()
+
+	stale := snapshot == nil || time.Since(snapshot.Stations.LastUpdated) > c.maxAge
+	if stale && time.Since(loadedAt) > reloadInterval {
+		if err := c.Refresh(); err != nil && snapshot == nil {
+			return nil, err
+		}
+
+		c.mu.RLock()
+		snapshot = c.snapshot
+		c.mu.RUnlock()
+	}
+
+	if snapshot == nil {
+		return nil, errors.New("Data Not Found")
+	}
+	return snapshot, nil
+}
+
+// Latest returns the cached snapshot, with only one station when kioskId
+// is not empty
+func (c *Cache) Latest(kioskId string) (*Latest, int, error) {
+	var kiosk int
+	if len(kioskId) > 0 {
+		var err error
+		if kiosk, err = strconv.Atoi(kioskId); err != nil {
+			return nil, http.StatusBadRequest, errors.New("Invalid kioskId format")
+		}
+	}
+
+	snapshot, err := c.Get()
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+
+	age := time.Since(snapshot.Stations.LastUpdated)
+	resp := Latest{
+		At:       snapshot.Stations.LastUpdated.UTC(),
+		Age:      int64(age.Seconds()),
+		Stale:    age > c.maxAge,
+		Stations: snapshot.Stations,
+		Weather:  snapshot.Weather,
+	}
+
+	if len(kioskId) > 0 {
+		station, ok := findStation(snapshot.Stations, kiosk)
+		if !ok {
+			return nil, http.StatusNotFound, errors.New("Data Not Found")
+		}
+		resp.Stations = station
+	}
+
+	return &resp, http.StatusOK, nil
+}
+
+// findStation returns a copy of the snapshot with only the station, the
+// cached snapshot is shared and never modified
+func findStation(stations *rideindego.FetchResponse, kioskId int) (*rideindego.FetchResponse, bool) {
+	for _, feature := range stations.Features {
+		if feature.Properties.KioskID == kioskId {
+			return &rideindego.FetchResponse{
+				Type:        stations.Type,
+				Features:    []rideindego.Features{feature},
+				LastUpdated: stations.LastUpdated,
+				Total:       1,
+			}, true
+		}
+	}
+	return nil, false
+}
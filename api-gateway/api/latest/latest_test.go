@@ -0,0 +1,99 @@
+package latest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/api/rideindego"
+)
+
+func newCache(lastUpdated time.Time) *Cache {
+	return &Cache{
+		maxAge: time.Hour,
+		snapshot: &Snapshot{
+			Stations: &rideindego.FetchResponse{
+				Type: "FeatureCollection",
+				Features: []rideindego.Features{
+					{Properties: rideindego.Properties{KioskID: 3005}},
+					{Properties: rideindego.Properties{KioskID: 3006}},
+				},
+				LastUpdated: lastUpdated,
+			},
+		},
+		// loaded just now, so a stale snapshot is not reloaded
+		loadedAt: time.Now(),
+	}
+}
+
+func TestLatest(t *testing.T) {
+	scenarios := []struct {
+		name             string
+		lastUpdated      time.Time
+		kioskId          string
+		expectedStatus   int
+		expectedStale    bool
+		expectedStations int
+	}{
+		{
+			name:             "Fresh snapshot",
+			lastUpdated:      time.Now().Add(-10 * time.Minute),
+			expectedStatus:   http.StatusOK,
+			expectedStations: 2,
+		},
+		{
+			name:             "Stale snapshot",
+			lastUpdated:      time.Now().Add(-3 * time.Hour),
+			expectedStatus:   http.StatusOK,
+			expectedStale:    true,
+			expectedStations: 2,
+		},
+		{
+			name:             "One station",
+			lastUpdated:      time.Now().Add(-10 * time.Minute),
+			kioskId:          "3006",
+			expectedStatus:   http.StatusOK,
+			expectedStations: 1,
+		},
+		{
+			name:           "Fail - Station not found",
+			lastUpdated:    time.Now(),
+			kioskId:        "1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Fail - KioskId not valid",
+			lastUpdated:    time.Now(),
+			kioskId:        "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			cache := newCache(ts.lastUpdated)
+
+			resp, httpCode, _ := cache.Latest(ts.kioskId)
+			if httpCode != ts.expectedStatus {
+				t.Fatalf("Expected status %d but got %d", ts.expectedStatus, httpCode)
+			}
+			if resp == nil {
+				return
+			}
+
+			if resp.Stale != ts.expectedStale {
+				t.Errorf("Expected stale %v but got %v", ts.expectedStale, resp.Stale)
+			}
+			if n := len(resp.Stations.Features); n != ts.expectedStations {
+				t.Errorf("Expected %d stations but got %d", ts.expectedStations, n)
+			}
+		})
+	}
+
+	// the cached snapshot is shared and must not be modified
+	cache := newCache(time.Now())
+	cache.Latest("3005")
+	if n := len(cache.snapshot.Stations.Features); n != 2 {
+		t.Errorf("Expected cached snapshot keeps 2 stations but got %d", n)
+	}
+}
@@ -60,3 +60,22 @@ type FetchResponse struct {
 	Weather    []Weather `json:"weather"`
 	Wind       Wind      `json:"wind"`
 }
+
+// StoreResult is the outcome of FetchAndStore. Details is the number of
+// weather condition rows written
+// StoreResult is the outcome of one store. RawFetchID is fetch_id of the
+// archived body, FetchID when the observation was stored
+type StoreResult struct {
+	Status     string
+	FetchID    string
+	RawFetchID string
+	Details    int
+}
+
+// Rows returns the number of rows written, master row included
+func (s StoreResult) Rows() int {
+	if s.Status != StatusStored {
+		return 0
+	}
+	return 1 + s.Details
+}
@@ -17,8 +17,20 @@ import (
 const (
 	Timeout = 10 * time.Second
 	BaseURL = "https://api.openweathermap.org/data/2.5/weather"
+
+	StatusStored    = "stored"
+	StatusUnchanged = "unchanged"
 )
 
+// free plan of Open Weather is rate limited, keep the retry short
+var RetryPolicy = client.RetryPolicy{
+	MaxAttempts:      3,
+	BaseDelay:        time.Second,
+	MaxDelay:         10 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  10 * time.Minute,
+}
+
 type Service struct {
 	db  dbase.DBService
 	cfg *config.EnvParams
@@ -28,36 +40,105 @@ func NewService(db dbase.DBService, cfg *config.EnvParams) *Service {
 	return &Service{db: db, cfg: cfg}
 }
 
-func (o *Service) FetchAndStore() (int, error) {
+// FetchAndStore fetch the current weather and store it without link to
+// any Indego snapshot
+func (o *Service) FetchAndStore() (StoreResult, int, error) {
+	rawData, httpStatus, err := o.Fetch()
+	if err != nil {
+		return StoreResult{}, httpStatus, err
+	}
+
+	result, err := o.Store(rawData, "")
+	if err != nil {
+		return StoreResult{}, http.StatusInternalServerError, err
+	}
+
+	return result, http.StatusOK, nil
+}
+
+// Fetch returns the upstream body as is
+func (o *Service) Fetch() ([]byte, int, error) {
 	params := url.Values{}
 	params.Add("q", "Philadelphia")
 	params.Add("appid", o.cfg.OpenWeather.APIKey)
 
 	url := fmt.Sprintf("%s?%s", BaseURL, params.Encode())
-	rawData, httpStatus, err := client.SendHTTPRequest(http.MethodGet, Timeout, nil, url)
-	if err != nil {
-		return httpStatus, err
-	}
+	return client.SendHTTPRequest(http.MethodGet, Timeout, nil, url, RetryPolicy)
+}
 
+// Store decode the upstream body and save it together with the body itself.
+// It returns StatusUnchanged when the same observation already stored, the
+// body is then archived alone under its own fetch_id. snapshotID is
+// fetch_id of the Indego snapshot to link with
+func (o *Service) Store(rawData []byte, snapshotID string) (StoreResult, error) {
 	// get json data
 	var jsonData FetchResponse
-	err = client.GetJSON(rawData, &jsonData)
+	err := client.GetJSON(rawData, &jsonData)
 	if err != nil {
-		return http.StatusInternalServerError, errors.New("Error reading response body")
+		return StoreResult{}, errors.New("Error reading response body")
 	}
 
-	// save to database
-	err = o.storeToDB(&jsonData)
+	result, err := o.storeToDB(&jsonData, rawData, snapshotID)
+	if errors.Is(err, dbase.ErrSnapshotExists) {
+		raw := dbase.RawPayload{FetchID: result.FetchID, Source: dbase.RawSourceOpenWeather, Body: rawData}
+		if err := o.db.StoreRawPayload(context.Background(), &raw); err != nil {
+			return StoreResult{}, errors.New("Error while store Openweather data")
+		}
+		return StoreResult{Status: StatusUnchanged, RawFetchID: result.FetchID}, nil
+	}
 	if err != nil {
-		return http.StatusInternalServerError, errors.New("Error while store Openweather data")
+		return StoreResult{}, errors.New("Error while store Openweather data")
 	}
 
-	return http.StatusOK, nil
+	result.Status = StatusStored
+	return result, nil
 }
 
-func (o *Service) storeToDB(fetchResponse *FetchResponse) error {
+func (o *Service) storeToDB(fetchResponse *FetchResponse, rawData []byte, snapshotID string) (StoreResult, error) {
 	fetchID := uuid.NewString()
 
+	paramStoreData := composeStoreData(fetchResponse, fetchID)
+	paramStoreData.SnapshotID = snapshotID
+	paramStoreData.Raw = &dbase.RawPayload{
+		FetchID: fetchID,
+		Source:  dbase.RawSourceOpenWeather,
+		Body:    rawData,
+	}
+	err := o.db.StoreOpenWeather(context.Background(), paramStoreData)
+	return StoreResult{FetchID: fetchID, RawFetchID: fetchID, Details: len(paramStoreData.Details)}, err
+}
+
+// Reprocess decode the stored raw payload of observation fetchID again and
+// replace the stored rows of the observation. It returns the difference
+// between the stored and the new rows, nothing is replaced on dryRun
+func (o *Service) Reprocess(fetchID string, dryRun bool) ([]string, error) {
+	ctx := context.Background()
+
+	raw, err := o.db.SearchRawPayload(ctx, fetchID)
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonData FetchResponse
+	if err := client.GetJSON(raw.Body, &jsonData); err != nil {
+		return nil, err
+	}
+
+	current, err := o.db.ReadOpenWeather(ctx, fetchID)
+	if err != nil {
+		return nil, err
+	}
+
+	reprocessed := composeStoreData(&jsonData, fetchID)
+	changes := diffStoreData(current, reprocessed)
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+
+	return changes, o.db.ReplaceOpenWeather(ctx, reprocessed)
+}
+
+func composeStoreData(fetchResponse *FetchResponse, fetchID string) dbase.ParamStoreOpenWeather {
 	// save master
 	master := &dbase.OpenWeatherMaster{
 		FetchID:       fetchID,
@@ -101,12 +182,39 @@ func (o *Service) storeToDB(fetchResponse *FetchResponse) error {
 		})
 	}
 
-	err := o.db.StoreOpenWeather(context.Background(), master, details)
-	return err
+	return dbase.ParamStoreOpenWeather{
+		Master:  master,
+		Details: details,
+	}
+}
+
+func diffStoreData(current, reprocessed dbase.ParamStoreOpenWeather) []string {
+	// coordinate read from database is formatted by PostGIS
+	var x, y float64
+	if _, err := fmt.Sscanf(current.Master.Coord, "POINT(%f %f)", &x, &y); err == nil {
+		current.Master.Coord = fmt.Sprintf("POINT(%f %f)", x, y)
+	}
+
+	// idx is serial on database, compare by position
+	for i, detail := range current.Details {
+		detail.Index = i
+	}
+
+	var changes []string
+	changes = append(changes, dbase.DiffRows("openweather_master",
+		func(m *dbase.OpenWeatherMaster) string { return "fetch_id=" + m.FetchID },
+		[]*dbase.OpenWeatherMaster{current.Master}, []*dbase.OpenWeatherMaster{reprocessed.Master})...)
+	changes = append(changes, dbase.DiffRows("openweather_weather",
+		func(d *dbase.OpenWewatherDetail) string { return fmt.Sprintf("idx=%d", d.Index) },
+		current.Details, reprocessed.Details)...)
+
+	return changes
 }
 
-func (o *Service) Search(at time.Time) (*FetchResponse, int, error) {
-	tbl, err := o.db.SearchOpenWeather(context.Background(), at)
+// Search find the weather of the Indego snapshot with last_update q.At,
+// q.Mode and q.MaxAge apply when the snapshot has no linked weather
+func (o *Service) Search(q dbase.SnapshotQuery) (*FetchResponse, int, error) {
+	tbl, err := o.db.SearchOpenWeather(context.Background(), q)
 	if err != nil {
 		return nil, http.StatusNotFound, err
 	}
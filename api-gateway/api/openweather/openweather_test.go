@@ -19,7 +19,7 @@ var (
 func TestSearch(t *testing.T) {
 	service := NewService(db, cfg)
 	at := time.Date(2024, 11, 21, 0, 0, 0, 0, time.UTC)
-	resp, _, err := service.Search(at)
+	resp, _, err := service.Search(database.SnapshotQuery{At: at})
 	if err != nil {
 		fmt.Printf("err: %v\n", err)
 		return
@@ -31,7 +31,7 @@ func TestSearch(t *testing.T) {
 func TestFetch(t *testing.T) {
 	t.Run("test function FetchData", func(t *testing.T) {
 		service := NewService(db, cfg)
-		httpCode, err := service.FetchAndStore()
+		_, httpCode, err := service.FetchAndStore()
 		if err != nil {
 			t.Errorf("Expected no error, but error occur %s\n", err)
 			return
@@ -0,0 +1,117 @@
+package rideindego
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geometry       `json:"geometry"`
+}
+
+// BBoxArea returns GeoJSON Polygon of bbox minLon,minLat,maxLon,maxLat
+func BBoxArea(bbox string) (string, error) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		return "", errors.New("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return "", errors.New("Invalid bbox format")
+		}
+		v[i] = f
+	}
+
+	minLon, minLat, maxLon, maxLat := v[0], v[1], v[2], v[3]
+	if minLon >= maxLon || minLat >= maxLat {
+		return "", errors.New("bbox min must be lower than max")
+	}
+	if !validPosition([]float64{minLon, minLat}) || !validPosition([]float64{maxLon, maxLat}) {
+		return "", errors.New("bbox out of range")
+	}
+
+	return fmt.Sprintf(`{"type":"Polygon","coordinates":[[[%[1]g,%[2]g],[%[3]g,%[2]g],[%[3]g,%[4]g],[%[1]g,%[4]g],[%[1]g,%[2]g]]]}`,
+		minLon, minLat, maxLon, maxLat), nil
+}
+
+// PolygonArea validate GeoJSON Polygon or MultiPolygon, as geometry or
+// as Feature, and returns the geometry
+func PolygonArea(body []byte) (string, error) {
+	var geo geometry
+	if err := json.Unmarshal(body, &geo); err != nil {
+		return "", errors.New("Invalid GeoJSON")
+	}
+	if geo.Type == "Feature" && geo.Geometry != nil {
+		geo = *geo.Geometry
+	}
+
+	var polygons [][][][]float64
+	switch geo.Type {
+	case "Polygon":
+		var polygon [][][]float64
+		if err := json.Unmarshal(geo.Coordinates, &polygon); err != nil {
+			return "", errors.New("Invalid Polygon coordinates")
+		}
+		polygons = append(polygons, polygon)
+	case "MultiPolygon":
+		if err := json.Unmarshal(geo.Coordinates, &polygons); err != nil {
+			return "", errors.New("Invalid MultiPolygon coordinates")
+		}
+	default:
+		return "", errors.New("GeoJSON must be a Polygon or MultiPolygon")
+	}
+
+	if len(polygons) == 0 {
+		return "", errors.New("Invalid Polygon coordinates")
+	}
+	for _, polygon := range polygons {
+		if err := validPolygon(polygon); err != nil {
+			return "", err
+		}
+	}
+
+	area, _ := json.Marshal(struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}{geo.Type, geo.Coordinates})
+	return string(area), nil
+}
+
+// validPolygon check each ring has at least 4 positions and is closed
+func validPolygon(polygon [][][]float64) error {
+	if len(polygon) == 0 {
+		return errors.New("Polygon must have at least one ring")
+	}
+
+	for _, ring := range polygon {
+		if len(ring) < 4 {
+			return errors.New("Polygon ring must have at least 4 positions")
+		}
+		for _, position := range ring {
+			if !validPosition(position) {
+				return errors.New("Invalid Polygon position")
+			}
+		}
+
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return errors.New("Polygon ring must be closed")
+		}
+	}
+
+	return nil
+}
+
+func validPosition(position []float64) bool {
+	return len(position) >= 2 &&
+		position[0] >= -180 && position[0] <= 180 &&
+		position[1] >= -90 && position[1] <= 90
+}
@@ -0,0 +1,64 @@
+package rideindego
+
+import (
+	"testing"
+)
+
+func TestBBoxArea(t *testing.T) {
+	area, err := BBoxArea("-75.18,39.94,-75.14,39.96")
+	if err != nil {
+		t.Fatalf("Expected no error, but error occur %s", err)
+	}
+
+	expected := `{"type":"Polygon","coordinates":[[[-75.18,39.94],[-75.14,39.94],[-75.14,39.96],[-75.18,39.96],[-75.18,39.94]]]}`
+	if area != expected {
+		t.Errorf("Expected %s but got %s", expected, area)
+	}
+
+	for _, bbox := range []string{"", "-75.18,39.94,-75.14", "-75.14,39.94,-75.18,39.96", "a,b,c,d", "-75,39,-75,200"} {
+		if _, err := BBoxArea(bbox); err == nil {
+			t.Errorf("Expected error for bbox %q", bbox)
+		}
+	}
+}
+
+func TestPolygonArea(t *testing.T) {
+	scenarios := []struct {
+		name        string
+		body        string
+		expectedErr bool
+	}{
+		{
+			name: "Polygon",
+			body: `{"type":"Polygon","coordinates":[[[-75.18,39.94],[-75.14,39.94],[-75.14,39.96],[-75.18,39.94]]]}`,
+		},
+		{
+			name: "MultiPolygon as Feature",
+			body: `{"type":"Feature","properties":{},"geometry":{"type":"MultiPolygon","coordinates":[[[[-75.18,39.94],[-75.14,39.94],[-75.14,39.96],[-75.18,39.94]]]]}}`,
+		},
+		{
+			name:        "Point is not an area",
+			body:        `{"type":"Point","coordinates":[-75.18,39.94]}`,
+			expectedErr: true,
+		},
+		{
+			name:        "Ring not closed",
+			body:        `{"type":"Polygon","coordinates":[[[-75.18,39.94],[-75.14,39.94],[-75.14,39.96],[-75.18,39.96]]]}`,
+			expectedErr: true,
+		},
+		{
+			name:        "Not JSON",
+			body:        `POLYGON((-75.18 39.94))`,
+			expectedErr: true,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			_, err := PolygonArea([]byte(ts.body))
+			if (err != nil) != ts.expectedErr {
+				t.Errorf("Expected error %v but got %v", ts.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package rideindego
+
+import (
+	"sort"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+type CountChange struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Delta int `json:"delta"`
+}
+
+type StatusChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// StationChange is the change of one station between two snapshots.
+// KioskStatus is only set on status transition
+type StationChange struct {
+	KioskID                int           `json:"kioskId"`
+	Name                   string        `json:"name"`
+	BikesAvailable         CountChange   `json:"bikesAvailable"`
+	DocksAvailable         CountChange   `json:"docksAvailable"`
+	ElectricBikesAvailable CountChange   `json:"electricBikesAvailable"`
+	KioskStatus            *StatusChange `json:"kioskStatus,omitempty"`
+}
+
+type StationRef struct {
+	KioskID int    `json:"kioskId"`
+	Name    string `json:"name"`
+}
+
+// SnapshotDiff is the difference between the snapshots at From and To.
+// Stations without change are not listed
+type SnapshotDiff struct {
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Changed []StationChange `json:"changed"`
+	Added   []StationRef    `json:"added"`
+	Removed []StationRef    `json:"removed"`
+}
+
+// Diff resolve the snapshots of from and to the same way as Search and
+// returns the change of each station between them
+func (r *Service) Diff(from dbase.SnapshotQuery, to dbase.SnapshotQuery) (*SnapshotDiff, int, error) {
+	before, httpCode, err := r.Search(from, "", dbase.PropertiesFilter{})
+	if err != nil {
+		return nil, httpCode, err
+	}
+
+	after, httpCode, err := r.Search(to, "", dbase.PropertiesFilter{})
+	if err != nil {
+		return nil, httpCode, err
+	}
+
+	return diffSnapshots(before, after), httpCode, nil
+}
+
+func diffSnapshots(before *FetchResponse, after *FetchResponse) *SnapshotDiff {
+	diff := SnapshotDiff{
+		From:    before.LastUpdated.UTC(),
+		To:      after.LastUpdated.UTC(),
+		Changed: []StationChange{},
+		Added:   []StationRef{},
+		Removed: []StationRef{},
+	}
+
+	stationsBefore := make(map[int]*Properties, len(before.Features))
+	for i := range before.Features {
+		prop := &before.Features[i].Properties
+		stationsBefore[prop.KioskID] = prop
+	}
+
+	seen := make(map[int]bool, len(after.Features))
+	for i := range after.Features {
+		prop := &after.Features[i].Properties
+		seen[prop.KioskID] = true
+
+		old, ok := stationsBefore[prop.KioskID]
+		if !ok {
+			diff.Added = append(diff.Added, StationRef{KioskID: prop.KioskID, Name: prop.Name})
+			continue
+		}
+
+		change := StationChange{
+			KioskID:                prop.KioskID,
+			Name:                   prop.Name,
+			BikesAvailable:         countChange(old.BikesAvailable, prop.BikesAvailable),
+			DocksAvailable:         countChange(old.DocksAvailable, prop.DocksAvailable),
+			ElectricBikesAvailable: countChange(old.ElectricBikesAvailable, prop.ElectricBikesAvailable),
+		}
+		if old.KioskStatus != prop.KioskStatus {
+			change.KioskStatus = &StatusChange{From: old.KioskStatus, To: prop.KioskStatus}
+		}
+
+		if change.BikesAvailable.Delta != 0 || change.DocksAvailable.Delta != 0 ||
+			change.ElectricBikesAvailable.Delta != 0 || change.KioskStatus != nil {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+
+	for kioskID, prop := range stationsBefore {
+		if !seen[kioskID] {
+			diff.Removed = append(diff.Removed, StationRef{KioskID: kioskID, Name: prop.Name})
+		}
+	}
+
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].KioskID < diff.Changed[j].KioskID })
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].KioskID < diff.Added[j].KioskID })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].KioskID < diff.Removed[j].KioskID })
+
+	return &diff
+}
+
+func countChange(from int, to int) CountChange {
+	return CountChange{From: from, To: to, Delta: to - from}
+}
@@ -0,0 +1,74 @@
+package rideindego
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	station := func(kioskID, bikes, docks, electric int, status string) Features {
+		return Features{Properties: Properties{
+			KioskID:                kioskID,
+			Name:                   "station",
+			BikesAvailable:         bikes,
+			DocksAvailable:         docks,
+			ElectricBikesAvailable: electric,
+			KioskStatus:            status,
+		}}
+	}
+
+	before := &FetchResponse{
+		LastUpdated: time.Date(2024, 11, 8, 7, 0, 0, 0, time.UTC),
+		Features: []Features{
+			station(3005, 10, 5, 2, "Active"),
+			station(3006, 3, 12, 0, "Active"),
+			station(3007, 4, 4, 1, "Active"),
+		},
+	}
+	after := &FetchResponse{
+		LastUpdated: time.Date(2024, 11, 8, 9, 0, 0, 0, time.UTC),
+		Features: []Features{
+			station(3006, 3, 12, 0, "Active"),
+			station(3005, 2, 13, 0, "Active"),
+			station(3007, 4, 4, 1, "Unavailable"),
+			station(3010, 8, 7, 3, "Active"),
+		},
+	}
+
+	diff := diffSnapshots(before, after)
+
+	expectedChanged := []StationChange{
+		{
+			KioskID:                3005,
+			Name:                   "station",
+			BikesAvailable:         CountChange{From: 10, To: 2, Delta: -8},
+			DocksAvailable:         CountChange{From: 5, To: 13, Delta: 8},
+			ElectricBikesAvailable: CountChange{From: 2, To: 0, Delta: -2},
+		},
+		{
+			KioskID:                3007,
+			Name:                   "station",
+			BikesAvailable:         CountChange{From: 4, To: 4},
+			DocksAvailable:         CountChange{From: 4, To: 4},
+			ElectricBikesAvailable: CountChange{From: 1, To: 1},
+			KioskStatus:            &StatusChange{From: "Active", To: "Unavailable"},
+		},
+	}
+	if !reflect.DeepEqual(diff.Changed, expectedChanged) {
+		t.Errorf("Expected changed %+v but got %+v", expectedChanged, diff.Changed)
+	}
+
+	if expected := []StationRef{{KioskID: 3010, Name: "station"}}; !reflect.DeepEqual(diff.Added, expected) {
+		t.Errorf("Expected added %v but got %v", expected, diff.Added)
+	}
+	if len(diff.Removed) != 0 {
+		t.Errorf("Expected no removed station but got %v", diff.Removed)
+	}
+
+	// reversed, the added station is removed
+	diff = diffSnapshots(after, before)
+	if expected := []StationRef{{KioskID: 3010, Name: "station"}}; !reflect.DeepEqual(diff.Removed, expected) {
+		t.Errorf("Expected removed %v but got %v", expected, diff.Removed)
+	}
+}
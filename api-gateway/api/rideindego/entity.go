@@ -62,4 +62,67 @@ type FetchResponse struct {
 	Type        string     `json:"type"`
 	Features    []Features `json:"features"`
 	LastUpdated time.Time  `json:"last_updated"`
+
+	// Total is the number of stations matching the filter before
+	// pagination, it is not part of the Indego API
+	Total int `json:"-"`
+}
+
+// StoreResult is the outcome of FetchAndStore. FetchID refer to the stored
+// snapshot, or the existing one when Status is StatusUnchanged. The counts
+// are the number of rows written
+// StoreResult is the outcome of one store. RawFetchID is fetch_id of the
+// archived body, the one of FetchID when the snapshot was stored
+type StoreResult struct {
+	Status     string
+	FetchID    string
+	RawFetchID string
+	LastUpdate time.Time
+	Features   int
+	Properties int
+	Bikes      int
+}
+
+// Rows returns the number of rows written, master row included
+func (s StoreResult) Rows() int {
+	if s.Status != StatusStored {
+		return 0
+	}
+	return 1 + s.Features + s.Properties + s.Bikes
+}
+
+// HistoryPoint is the availability of a station at one snapshot, or the
+// average over one interval when the history is downsampled
+type HistoryPoint struct {
+	At                     time.Time `json:"at"`
+	BikesAvailable         float64   `json:"bikesAvailable"`
+	DocksAvailable         float64   `json:"docksAvailable"`
+	ClassicBikesAvailable  float64   `json:"classicBikesAvailable"`
+	ElectricBikesAvailable float64   `json:"electricBikesAvailable"`
+	SmartBikesAvailable    float64   `json:"smartBikesAvailable"`
+	KioskStatus            string    `json:"kioskStatus"`
+	Samples                int       `json:"samples"`
+}
+
+type History struct {
+	KioskID  int            `json:"kioskId"`
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Interval string         `json:"interval,omitempty"`
+	Points   []HistoryPoint `json:"points"`
+}
+
+// NearbyStation is a station with its distance in meters to the point of
+// the search
+type NearbyStation struct {
+	Distance float64 `json:"distance"`
+	Properties
+}
+
+type Nearby struct {
+	At       time.Time       `json:"at"`
+	Lat      float64         `json:"lat"`
+	Lon      float64         `json:"lon"`
+	Radius   float64         `json:"radius"`
+	Stations []NearbyStation `json:"stations"`
 }
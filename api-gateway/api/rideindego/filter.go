@@ -0,0 +1,200 @@
+package rideindego
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+const (
+	// MaxPageLimit is the largest limit of one page of stations
+	MaxPageLimit = 500
+	// MaxKioskIDs is the largest number of kioskIds in one request
+	MaxKioskIDs = 100
+)
+
+// sortColumns map numeric properties of the Indego API to the columns
+var sortColumns = map[string]string{
+	"id":                     "id",
+	"kioskId":                "kiosk_id",
+	"kioskType":              "kiosk_type",
+	"latitude":               "latitude",
+	"longitude":              "longitude",
+	"totalDocks":             "total_docks",
+	"bikesAvailable":         "bikes_available",
+	"docksAvailable":         "docks_available",
+	"trikesAvailable":        "trikes_available",
+	"smartBikesAvailable":    "smart_bikes_available",
+	"rewardBikesAvailable":   "reward_bikes_available",
+	"rewardDocksAvailable":   "reward_docks_available",
+	"classicBikesAvailable":  "classic_bikes_available",
+	"electricBikesAvailable": "electric_bikes_available",
+}
+
+// ParseFilter read the filter, sort and pagination parameters of the
+// stations endpoint. Sort is a numeric property, prefixed by - for
+// descending order
+func ParseFilter(params url.Values) (dbase.PropertiesFilter, error) {
+	filter := dbase.PropertiesFilter{
+		KioskStatus: params.Get("kioskStatus"),
+		Zip:         params.Get("zip"),
+	}
+
+	var err error
+	if filter.MinBikes, err = parseInt(params, "minBikes"); err != nil {
+		return filter, err
+	}
+	if filter.MinDocks, err = parseInt(params, "minDocks"); err != nil {
+		return filter, err
+	}
+	if filter.KioskType, err = parseInt(params, "kioskType"); err != nil {
+		return filter, err
+	}
+
+	if q := params.Get("kioskIds"); len(q) > 0 {
+		if filter.KioskIDs, err = parseKioskIDs(q); err != nil {
+			return filter, err
+		}
+	}
+
+	if q := params.Get("isVirtual"); len(q) > 0 {
+		isVirtual, err := strconv.ParseBool(q)
+		if err != nil {
+			return filter, errors.New("Invalid isVirtual format")
+		}
+		filter.IsVirtual = &isVirtual
+	}
+
+	if q := params.Get("sort"); len(q) > 0 {
+		column, ok := sortColumns[strings.TrimPrefix(q, "-")]
+		if !ok {
+			return filter, errors.New("Invalid sort property")
+		}
+		filter.Sort = column
+		filter.Desc = strings.HasPrefix(q, "-")
+	}
+
+	limit, err := parseInt(params, "limit")
+	if err != nil {
+		return filter, err
+	}
+	if limit != nil {
+		if len(filter.KioskIDs) > 0 {
+			return filter, errors.New("limit can not be used with kioskIds")
+		}
+		if *limit < 1 || *limit > MaxPageLimit {
+			return filter, errors.New("limit must be between 1 and 500")
+		}
+		filter.Limit = *limit
+	}
+
+	offset, err := parseInt(params, "offset")
+	if err != nil {
+		return filter, err
+	}
+	if offset != nil {
+		if *offset < 0 || limit == nil {
+			return filter, errors.New("offset must be positive and used with limit")
+		}
+		filter.Offset = *offset
+	}
+
+	return filter, nil
+}
+
+func parseInt(params url.Values, name string) (*int, error) {
+	q := params.Get(name)
+	if len(q) == 0 {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(q)
+	if err != nil {
+		return nil, errors.New("Invalid " + name + " format")
+	}
+	return &value, nil
+}
+
+// parseKioskIDs read comma separated kiosk IDs, duplicates are removed
+func parseKioskIDs(value string) ([]int, error) {
+	var (
+		ids  []int
+		seen = make(map[int]bool)
+	)
+
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, errors.New("Invalid kioskIds format")
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) > MaxKioskIDs {
+		return nil, errors.New("kioskIds can have at most 100 IDs")
+	}
+	return ids, nil
+}
+
+// MissingKioskIDs returns the requested kiosk IDs of filter missing in
+// resp, the snapshot selected by the filter. notFound are not in the
+// snapshot at all, notMatched are in the snapshot but excluded by the other
+// conditions of the filter. Both are in the requested order
+func (r *Service) MissingKioskIDs(filter dbase.PropertiesFilter, resp *FetchResponse) (notFound []int, notMatched []int, err error) {
+	notFound, notMatched = missingKioskIDs(filter.KioskIDs, resp), []int{}
+	if len(notFound) == 0 || onlyKioskIDs(filter) {
+		return notFound, notMatched, nil
+	}
+
+	// look up the missing IDs on the same snapshot without the other conditions
+	query := dbase.SnapshotQuery{At: resp.LastUpdated, Mode: dbase.ModeAfter}
+	unfiltered, _, err := r.Search(query, "", dbase.PropertiesFilter{KioskIDs: notFound})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inSnapshot := make(map[int]bool, len(unfiltered.Features))
+	for _, feature := range unfiltered.Features {
+		inSnapshot[feature.Properties.KioskID] = true
+	}
+
+	missing := notFound
+	notFound = []int{}
+	for _, id := range missing {
+		if inSnapshot[id] {
+			notMatched = append(notMatched, id)
+		} else {
+			notFound = append(notFound, id)
+		}
+	}
+	return notFound, notMatched, nil
+}
+
+// onlyKioskIDs is true when the filter has no condition but the kiosk IDs
+func onlyKioskIDs(filter dbase.PropertiesFilter) bool {
+	return len(filter.KioskStatus) == 0 && filter.MinBikes == nil && filter.MinDocks == nil &&
+		filter.IsVirtual == nil && len(filter.Zip) == 0 && filter.KioskType == nil && len(filter.Area) == 0
+}
+
+// missingKioskIDs returns the requested kiosk IDs which are not in the
+// response, in the requested order
+func missingKioskIDs(requested []int, resp *FetchResponse) []int {
+	found := make(map[int]bool, len(resp.Features))
+	for _, feature := range resp.Features {
+		found[feature.Properties.KioskID] = true
+	}
+
+	missing := []int{}
+	for _, id := range requested {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
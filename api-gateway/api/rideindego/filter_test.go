@@ -0,0 +1,61 @@
+package rideindego
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+// snapshotDB returns the stations of kioskIDs found in the filter, other
+// methods are not used
+type snapshotDB struct {
+	database.DBService
+	kioskIDs []int
+}
+
+func (d *snapshotDB) SearchRideIndego(ctx context.Context, q database.SnapshotQuery, kioskID string, filter database.PropertiesFilter) (database.SearchResRideIndego, error) {
+	res := database.SearchResRideIndego{
+		Master:          &database.RideIndegoMaster{TypeCollection: "FeatureCollection", LastUpdate: q.At},
+		PropertiesBikes: map[int][]*database.RideIndegoBikes{},
+	}
+
+	for i, id := range d.kioskIDs {
+		for _, requested := range filter.KioskIDs {
+			if id == requested {
+				res.Features = append(res.Features, &database.RideIndegoFeatures{FeatureID: i, GeometryCoordinate: "POINT(-75.1 39.9)"})
+				res.Properties = append(res.Properties, &database.RideIndegoProperties{FeatureID: i, KioskID: id})
+			}
+		}
+	}
+	return res, nil
+}
+
+func TestMissingKioskIDs(t *testing.T) {
+	service := NewService(&snapshotDB{kioskIDs: []int{3005, 3006, 3007}})
+	minBikes := 3
+
+	// 3006 excluded by minBikes, 3010 not in the snapshot
+	filter := database.PropertiesFilter{KioskIDs: []int{3005, 3006, 3010}, MinBikes: &minBikes}
+	resp := &FetchResponse{
+		LastUpdated: time.Date(2024, 11, 8, 1, 0, 0, 0, time.UTC),
+		Features:    []Features{{Properties: Properties{KioskID: 3005}}},
+	}
+
+	notFound, notMatched, err := service.MissingKioskIDs(filter, resp)
+	if err != nil {
+		t.Fatalf("Expected no error, but error occur %s\n", err)
+	}
+	if !reflect.DeepEqual(notFound, []int{3010}) || !reflect.DeepEqual(notMatched, []int{3006}) {
+		t.Errorf("Expected notFound [3010] and notMatched [3006] but got %v and %v", notFound, notMatched)
+	}
+
+	// without other condition, a missing ID is not in the snapshot
+	filter.MinBikes = nil
+	notFound, notMatched, _ = service.MissingKioskIDs(filter, resp)
+	if !reflect.DeepEqual(notFound, []int{3006, 3010}) || len(notMatched) != 0 {
+		t.Errorf("Expected notFound [3006 3010] but got %v and %v", notFound, notMatched)
+	}
+}
@@ -0,0 +1,67 @@
+package rideindego
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// MaxHistoryPoints limit the points of one history response
+	MaxHistoryPoints = 5000
+	// MinHistoryInterval is the smallest downsampling interval
+	MinHistoryInterval = time.Minute
+)
+
+// History returns the availability of one station for every snapshot
+// between from and to. When interval is not zero the snapshots are
+// averaged per interval
+func (r *Service) History(kioskID int, from time.Time, to time.Time, interval time.Duration) (*History, int, error) {
+	if from.After(to) {
+		return nil, http.StatusBadRequest, errors.New("from must be before to")
+	}
+	if interval != 0 && interval < MinHistoryInterval {
+		return nil, http.StatusBadRequest, errors.New("interval must be at least 1m")
+	}
+	if interval > 0 && to.Sub(from)/interval > MaxHistoryPoints {
+		return nil, http.StatusBadRequest, errors.New("Too many points, use a larger interval")
+	}
+
+	// one more row to detect the range has more points than allowed
+	rows, err := r.db.SearchStationHistory(context.Background(), kioskID, from, to, interval, MaxHistoryPoints+1)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read station history")
+	}
+	if len(rows) == 0 {
+		return nil, http.StatusNotFound, errors.New("Data Not Found")
+	}
+	if len(rows) > MaxHistoryPoints {
+		return nil, http.StatusBadRequest, errors.New("Too many points, use a larger interval")
+	}
+
+	history := History{
+		KioskID: kioskID,
+		From:    from.UTC(),
+		To:      to.UTC(),
+		Points:  make([]HistoryPoint, 0, len(rows)),
+	}
+	if interval > 0 {
+		history.Interval = interval.String()
+	}
+
+	for _, row := range rows {
+		history.Points = append(history.Points, HistoryPoint{
+			At:                     row.At.UTC(),
+			BikesAvailable:         row.BikesAvailable,
+			DocksAvailable:         row.DocksAvailable,
+			ClassicBikesAvailable:  row.ClassicBikesAvailable,
+			ElectricBikesAvailable: row.ElectricBikesAvailable,
+			SmartBikesAvailable:    row.SmartBikesAvailable,
+			KioskStatus:            row.KioskStatus,
+			Samples:                row.Samples,
+		})
+	}
+
+	return &history, http.StatusOK, nil
+}
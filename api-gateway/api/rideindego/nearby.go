@@ -0,0 +1,50 @@
+package rideindego
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+const (
+	// DefaultNearbyRadius is the radius in meters when not given
+	DefaultNearbyRadius = 500
+	// MaxNearbyRadius is the largest radius in meters
+	MaxNearbyRadius = 10000
+)
+
+// Nearby returns the stations of the snapshot selected by q within the
+// radius of the point, sorted by distance
+func (r *Service) Nearby(q dbase.SnapshotQuery, nearby dbase.NearbyQuery) (*Nearby, int, error) {
+	if nearby.Lat < -90 || nearby.Lat > 90 || nearby.Lon < -180 || nearby.Lon > 180 {
+		return nil, http.StatusBadRequest, errors.New("Invalid lat or lon")
+	}
+	if nearby.Radius <= 0 || nearby.Radius > MaxNearbyRadius {
+		return nil, http.StatusBadRequest, errors.New("radius must be between 1 and 10000 meters")
+	}
+
+	tbl, err := r.db.SearchNearbyStations(context.Background(), q, nearby)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+
+	resp := Nearby{
+		At:       tbl.Master.LastUpdate.UTC(),
+		Lat:      nearby.Lat,
+		Lon:      nearby.Lon,
+		Radius:   nearby.Radius,
+		Stations: make([]NearbyStation, 0, len(tbl.Properties)),
+	}
+
+	for _, prop := range tbl.Properties {
+		resp.Stations = append(resp.Stations, NearbyStation{
+			Distance:   math.Round(prop.Distance*10) / 10,
+			Properties: parseProperties(&prop.RideIndegoProperties, tbl.PropertiesBikes[prop.FeatureID]),
+		})
+	}
+
+	return &resp, http.StatusOK, nil
+}
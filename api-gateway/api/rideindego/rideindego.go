@@ -16,8 +16,20 @@ const (
 	Timeout          = 10 * time.Second
 	LastUpdateLayout = "2006-01-02T15:04:05.999Z"
 	BaseURL          = "https://www.rideindego.com/stations/json/"
+
+	StatusStored    = "stored"
+	StatusUnchanged = "unchanged"
 )
 
+// Indego refresh the data every minute, a short outage is worth to wait
+var RetryPolicy = client.RetryPolicy{
+	MaxAttempts:      4,
+	BaseDelay:        2 * time.Second,
+	MaxDelay:         30 * time.Second,
+	BreakerThreshold: 8,
+	BreakerCooldown:  5 * time.Minute,
+}
+
 type Service struct {
 	db dbase.DBService
 }
@@ -26,17 +38,27 @@ func NewService(db dbase.DBService) *Service {
 	return &Service{db: db}
 }
 
-func (r *Service) Search(at time.Time, kioskId string) (*FetchResponse, int, error) {
-	tbl, err := r.db.SearchRideIndego(context.Background(), at, kioskId)
+// Search find the snapshot selected by q, with only one station when
+// kioskId is not empty. The stations are filtered, sorted and paginated
+// by filter
+func (r *Service) Search(q dbase.SnapshotQuery, kioskId string, filter dbase.PropertiesFilter) (*FetchResponse, int, error) {
+	tbl, err := r.db.SearchRideIndego(context.Background(), q, kioskId, filter)
 	if err != nil {
 		return nil, http.StatusNotFound, err
 	}
 
-	// compose return value
-	features := []Features{}
+	featureByID := make(map[int]*dbase.RideIndegoFeatures, len(tbl.Features))
 	for _, feature := range tbl.Features {
-		fID := feature.FeatureID
-		properties := parseProperties(tbl.Properties[fID], tbl.PropertiesBikes[fID])
+		featureByID[feature.FeatureID] = feature
+	}
+
+	// compose return value in the order of properties
+	features := []Features{}
+	for _, prop := range tbl.Properties {
+		feature, ok := featureByID[prop.FeatureID]
+		if !ok {
+			continue
+		}
 
 		features = append(features, Features{
 			Type: feature.FeatureType,
@@ -44,7 +66,7 @@ func (r *Service) Search(at time.Time, kioskId string) (*FetchResponse, int, err
 				Type:        feature.GeometryType,
 				Coordinates: unmarshalCoordinate(feature.GeometryCoordinate),
 			},
-			Properties: properties,
+			Properties: parseProperties(prop, tbl.PropertiesBikes[prop.FeatureID]),
 		})
 	}
 
@@ -52,36 +74,115 @@ func (r *Service) Search(at time.Time, kioskId string) (*FetchResponse, int, err
 		Type:        tbl.Master.TypeCollection,
 		Features:    features,
 		LastUpdated: tbl.Master.LastUpdate.UTC(),
+		Total:       tbl.Total,
 	}
 
 	return &resp, http.StatusOK, nil
 }
 
-func (r *Service) FetchAndStore() (int, error) {
+// FetchAndStore returns StatusUnchanged when the snapshot with the same
+// last_updated already stored, otherwise StatusStored
+func (r *Service) FetchAndStore() (StoreResult, int, error) {
 
 	// fetch data
-	rawData, httpStatus, err := client.SendHTTPRequest(http.MethodGet, Timeout, nil, BaseURL)
+	rawData, httpStatus, err := r.Fetch()
+	if err != nil {
+		return StoreResult{}, httpStatus, err
+	}
+
+	// save to database
+	result, err := r.Store(rawData)
 	if err != nil {
-		return httpStatus, err
+		return StoreResult{}, http.StatusInternalServerError, err
 	}
+	return result, http.StatusOK, nil
+}
 
+// Fetch returns the upstream body as is
+func (r *Service) Fetch() ([]byte, int, error) {
+	return client.SendHTTPRequest(http.MethodGet, Timeout, nil, BaseURL, RetryPolicy)
+}
+
+// Store decode the upstream body and save the snapshot together with the
+// body itself. It returns StatusUnchanged when the snapshot with the same
+// last_updated already stored, the body is then archived alone under its
+// own fetch_id
+func (r *Service) Store(rawData []byte) (StoreResult, error) {
 	// get json data
 	var jsonData FetchResponse
-	err = client.GetJSON(rawData, &jsonData)
+	err := client.GetJSON(rawData, &jsonData)
 	if err != nil {
-		return http.StatusInternalServerError, errors.New("Error reading response body")
+		return StoreResult{}, errors.New("Error reading response body")
 	}
 
-	// save to database
-	err = r.storeToDB(&jsonData)
+	result, err := r.storeToDB(&jsonData, rawData)
+	if errors.Is(err, dbase.ErrSnapshotExists) {
+		raw := dbase.RawPayload{FetchID: result.RawFetchID, Source: dbase.RawSourceRideIndego, Body: rawData}
+		if err := r.db.StoreRawPayload(context.Background(), &raw); err != nil {
+			return StoreResult{}, errors.New("Error while store RideIndego data")
+		}
+		return StoreResult{Status: StatusUnchanged, FetchID: result.FetchID, RawFetchID: result.RawFetchID}, nil
+	}
 	if err != nil {
-		return http.StatusInternalServerError, errors.New("Error while store RideIndego data")
+		return StoreResult{}, errors.New("Error while store RideIndego data")
 	}
-	return http.StatusOK, nil
+
+	result.Status = StatusStored
+	return result, nil
 }
 
-func (r *Service) storeToDB(fetchResponse *FetchResponse) error {
+func (r *Service) storeToDB(fetchResponse *FetchResponse, rawData []byte) (StoreResult, error) {
 	fetchID := uuid.NewString()
+
+	paramStoreData := composeStoreData(fetchResponse, fetchID)
+	paramStoreData.Raw = &dbase.RawPayload{
+		FetchID: fetchID,
+		Source:  dbase.RawSourceRideIndego,
+		Body:    rawData,
+	}
+
+	storedID, err := r.db.StoreRideIndego(context.Background(), paramStoreData)
+	return StoreResult{
+		FetchID:    storedID,
+		RawFetchID: fetchID,
+		LastUpdate: fetchResponse.LastUpdated,
+		Features:   len(paramStoreData.Features),
+		Properties: len(paramStoreData.Properties),
+		Bikes:      len(paramStoreData.PropertiesBikes),
+	}, err
+}
+
+// Reprocess decode the stored raw payload of snapshot fetchID again and
+// replace the stored rows of the snapshot. It returns the difference between
+// the stored and the new rows, nothing is replaced on dryRun
+func (r *Service) Reprocess(fetchID string, dryRun bool) ([]string, error) {
+	ctx := context.Background()
+
+	raw, err := r.db.SearchRawPayload(ctx, fetchID)
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonData FetchResponse
+	if err := client.GetJSON(raw.Body, &jsonData); err != nil {
+		return nil, err
+	}
+
+	current, err := r.db.ReadRideIndego(ctx, fetchID)
+	if err != nil {
+		return nil, err
+	}
+
+	reprocessed := composeStoreData(&jsonData, fetchID)
+	changes := diffStoreData(current, reprocessed)
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+
+	return changes, r.db.ReplaceRideIndego(ctx, reprocessed)
+}
+
+func composeStoreData(fetchResponse *FetchResponse, fetchID string) dbase.ParamStoreRideIndego {
 	featureID := int(fetchResponse.LastUpdated.Unix())
 
 	// save master
@@ -106,7 +207,7 @@ func (r *Service) storeToDB(fetchResponse *FetchResponse) error {
 			FeatureID:          featureID,
 			FeatureType:        val.Type,
 			GeometryType:       val.Geometry.Type,
-			GeometryCoordinate: fmt.Sprintf("POINT(%f %f)", val.Geometry.Coordinates[0], val.Geometry.Coordinates[1]),
+			GeometryCoordinate: formatCoordinate(val.Geometry.Coordinates),
 		})
 
 		properties = append(properties, &dbase.RideIndegoProperties{
@@ -128,7 +229,7 @@ func (r *Service) storeToDB(fetchResponse *FetchResponse) error {
 			PublicText:             val.Properties.PublicText,
 			TotalDocks:             val.Properties.TotalDocks,
 			AddressCity:            val.Properties.AddressCity,
-			Coordinates:            fmt.Sprintf("POINT(%f %f)", val.Properties.Coordinates[0], val.Properties.Coordinates[1]),
+			Coordinates:            formatCoordinate(val.Properties.Coordinates),
 			KioskStatus:            val.Properties.KioskStatus,
 			AddressState:           val.Properties.AddressState,
 			IsEventBased:           val.Properties.IsEventBased,
@@ -160,13 +261,47 @@ func (r *Service) storeToDB(fetchResponse *FetchResponse) error {
 		}
 	}
 
-	paramStoreData := dbase.ParamStoreRideIndego{
+	return dbase.ParamStoreRideIndego{
 		Master:          master,
 		Features:        features,
 		Properties:      properties,
 		PropertiesBikes: propBikes,
 	}
-	return r.db.StoreRideIndego(context.Background(), paramStoreData)
+}
+
+func diffStoreData(current, reprocessed dbase.ParamStoreRideIndego) []string {
+	// coordinates read from database are formatted by PostGIS
+	for _, feature := range current.Features {
+		feature.GeometryCoordinate = formatCoordinate(unmarshalCoordinate(feature.GeometryCoordinate))
+	}
+	for _, prop := range current.Properties {
+		prop.Coordinates = formatCoordinate(unmarshalCoordinate(prop.Coordinates))
+	}
+
+	// idx is serial on database, compare by position inside the feature
+	position := make(map[int]int)
+	for _, bike := range current.PropertiesBikes {
+		bike.Index = position[bike.FeatureID]
+		position[bike.FeatureID]++
+	}
+
+	var changes []string
+	changes = append(changes, dbase.DiffRows("rideindego_master",
+		func(m *dbase.RideIndegoMaster) string { return "fetch_id=" + m.FetchID },
+		[]*dbase.RideIndegoMaster{current.Master}, []*dbase.RideIndegoMaster{reprocessed.Master})...)
+	changes = append(changes, dbase.DiffRows("rideindego_features",
+		func(f *dbase.RideIndegoFeatures) string { return fmt.Sprintf("feat_id=%d", f.FeatureID) },
+		current.Features, reprocessed.Features)...)
+	changes = append(changes, dbase.DiffRows("rideindego_properties",
+		func(p *dbase.RideIndegoProperties) string {
+			return fmt.Sprintf("feat_id=%d id=%d", p.FeatureID, p.PropertiesID)
+		},
+		current.Properties, reprocessed.Properties)...)
+	changes = append(changes, dbase.DiffRows("rideindego_properties_bikes",
+		func(b *dbase.RideIndegoBikes) string { return fmt.Sprintf("feat_id=%d idx=%d", b.FeatureID, b.Index) },
+		current.PropertiesBikes, reprocessed.PropertiesBikes)...)
+
+	return changes
 }
 
 func parseProperties(prop *dbase.RideIndegoProperties, bikes []*dbase.RideIndegoBikes) Properties {
@@ -222,6 +357,13 @@ func parseProperties(prop *dbase.RideIndegoProperties, bikes []*dbase.RideIndego
 	}
 }
 
+func formatCoordinate(coordinate []float64) string {
+	if len(coordinate) < 2 {
+		return ""
+	}
+	return fmt.Sprintf("POINT(%f %f)", coordinate[0], coordinate[1])
+}
+
 func unmarshalCoordinate(coordinate string) []float64 {
 	var lon, lat float64
 
@@ -1,6 +1,7 @@
 package rideindego
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -9,16 +10,19 @@ import (
 
 	"github.com/arthben/BackendGolang/api-gateway/internal/config"
 	"github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/jmoiron/sqlx"
 )
 
 var (
 	db database.DBService
+	// conn cleans up the rows stored by the tests
+	conn *sqlx.DB
 )
 
 func TestSearch(t *testing.T) {
 	service := NewService(db)
 	at := time.Date(2024, 11, 21, 0, 0, 0, 0, time.UTC)
-	resp, _, err := service.Search(at, "3009")
+	resp, _, err := service.Search(database.SnapshotQuery{At: at}, "3009", database.PropertiesFilter{})
 	if err != nil {
 		fmt.Printf("err: %v\n", err)
 		return
@@ -30,7 +34,7 @@ func TestSearch(t *testing.T) {
 func TestFetch(t *testing.T) {
 	t.Run("test function FetchData", func(t *testing.T) {
 		service := NewService(db)
-		httpCode, err := service.FetchAndStore()
+		result, httpCode, err := service.FetchAndStore()
 		if err != nil {
 			t.Errorf("Expected no error, but error occur %s\n", err)
 			return
@@ -40,9 +44,57 @@ func TestFetch(t *testing.T) {
 			t.Errorf("Expected status %d but got response %d", http.StatusOK, httpCode)
 			return
 		}
+
+		if result.Status != StatusStored && result.Status != StatusUnchanged {
+			t.Errorf("Expected store status but got %s", result.Status)
+			return
+		}
 	})
 }
 
+func TestStoreUnchanged(t *testing.T) {
+	service := NewService(db)
+	snapshot := &FetchResponse{
+		Type:        "FeatureCollection",
+		Features:    []Features{},
+		LastUpdated: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	deleteSnapshot(t, snapshot.LastUpdated)
+	t.Cleanup(func() { deleteSnapshot(t, snapshot.LastUpdated) })
+
+	_, err := service.storeToDB(snapshot, nil)
+	if err != nil {
+		t.Errorf("Expected no error, but error occur %s\n", err)
+		return
+	}
+
+	_, err = service.storeToDB(snapshot, nil)
+	if !errors.Is(err, database.ErrSnapshotExists) {
+		t.Errorf("Expected error %v but got %v", database.ErrSnapshotExists, err)
+		return
+	}
+}
+
+// deleteSnapshot delete the snapshot with lastUpdate and all its rows
+func deleteSnapshot(t *testing.T, lastUpdate time.Time) {
+	tables := []string{
+		"rideindego_properties_bikes",
+		"rideindego_properties",
+		"rideindego_features",
+		"raw_payloads",
+		"rideindego_master",
+	}
+
+	for _, table := range tables {
+		sql := `DELETE FROM ` + table + ` WHERE fetch_id IN (
+					SELECT fetch_id FROM rideindego_master WHERE last_update = $1)`
+		if _, err := conn.Exec(sql, lastUpdate); err != nil {
+			t.Errorf("Expected no error while delete %s, but error occur %s\n", table, err)
+		}
+	}
+}
+
 func init() {
 	os.Chdir("../..")
 
@@ -59,4 +111,10 @@ func init() {
 	}
 
 	db = repo
+
+	conn, err = sqlx.Connect("postgres", cfg.DB.DSN)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		panic(0)
+	}
 }
@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"time"
+)
+
+// Bucket is the rollup of one station over one hour or one day. PctEmpty
+// and PctFull are the percent of snapshots without bike and without free
+// dock
+type Bucket struct {
+	At       time.Time `json:"at"`
+	Samples  int       `json:"samples"`
+	BikesMin int       `json:"bikesMin"`
+	BikesMax int       `json:"bikesMax"`
+	BikesAvg float64   `json:"bikesAvg"`
+	DocksMin int       `json:"docksMin"`
+	DocksMax int       `json:"docksMax"`
+	DocksAvg float64   `json:"docksAvg"`
+	PctEmpty float64   `json:"pctEmpty"`
+	PctFull  float64   `json:"pctFull"`
+}
+
+type StationStats struct {
+	KioskID     int       `json:"kioskId"`
+	Granularity string    `json:"granularity"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Buckets     []Bucket  `json:"buckets"`
+}
+
+// SystemBucket is the rollup of all stations over one hour or one day.
+// BikesAvg and DocksAvg are the average number of bikes and free docks in
+// the whole system, PctEmpty and PctFull the average over the stations
+type SystemBucket struct {
+	At       time.Time `json:"at"`
+	Stations int       `json:"stations"`
+	Samples  int       `json:"samples"`
+	BikesAvg float64   `json:"bikesAvg"`
+	DocksAvg float64   `json:"docksAvg"`
+	PctEmpty float64   `json:"pctEmpty"`
+	PctFull  float64   `json:"pctFull"`
+}
+
+type SystemStats struct {
+	Granularity string         `json:"granularity"`
+	From        time.Time      `json:"from"`
+	To          time.Time      `json:"to"`
+	Buckets     []SystemBucket `json:"buckets"`
+}
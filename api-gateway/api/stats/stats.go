@@ -0,0 +1,125 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+// MaxStatsBuckets limit the buckets of one stats response
+const MaxStatsBuckets = 5000
+
+type Service struct {
+	db dbase.DBService
+}
+
+func NewService(db dbase.DBService) *Service {
+	return &Service{db: db}
+}
+
+// Refresh rebuild the hourly and daily rollups of the hours and days
+// touched by the range from to
+func (s *Service) Refresh(from time.Time, to time.Time) error {
+	return s.db.RefreshStationRollups(context.Background(), from, to)
+}
+
+// Station returns the rollups of one station starting between from and to.
+// Empty granularity means hour
+func (s *Service) Station(kioskID int, granularity string, from time.Time, to time.Time) (*StationStats, int, error) {
+	granularity, httpCode, err := validate(granularity, from, to)
+	if err != nil {
+		return nil, httpCode, err
+	}
+
+	// one more row to detect the range has more buckets than allowed
+	rows, err := s.db.SearchStationRollups(context.Background(), granularity, kioskID, from, to, MaxStatsBuckets+1)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read station stats")
+	}
+	if len(rows) == 0 {
+		return nil, http.StatusNotFound, errors.New("Data Not Found")
+	}
+	if len(rows) > MaxStatsBuckets {
+		return nil, http.StatusBadRequest, errors.New("Too many buckets, use granularity day or a shorter range")
+	}
+
+	stats := StationStats{
+		KioskID:     kioskID,
+		Granularity: granularity,
+		From:        from.UTC(),
+		To:          to.UTC(),
+		Buckets:     make([]Bucket, 0, len(rows)),
+	}
+	for _, row := range rows {
+		stats.Buckets = append(stats.Buckets, Bucket{
+			At:       row.Bucket.UTC(),
+			Samples:  row.Samples,
+			BikesMin: row.BikesMin,
+			BikesMax: row.BikesMax,
+			BikesAvg: row.BikesAvg,
+			DocksMin: row.DocksMin,
+			DocksMax: row.DocksMax,
+			DocksAvg: row.DocksAvg,
+			PctEmpty: row.PctEmpty,
+			PctFull:  row.PctFull,
+		})
+	}
+
+	return &stats, http.StatusOK, nil
+}
+
+// System returns the rollups of all stations starting between from and to.
+// Empty granularity means hour
+func (s *Service) System(granularity string, from time.Time, to time.Time) (*SystemStats, int, error) {
+	granularity, httpCode, err := validate(granularity, from, to)
+	if err != nil {
+		return nil, httpCode, err
+	}
+
+	rows, err := s.db.SearchSystemRollups(context.Background(), granularity, from, to, MaxStatsBuckets+1)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error while read system stats")
+	}
+	if len(rows) == 0 {
+		return nil, http.StatusNotFound, errors.New("Data Not Found")
+	}
+	if len(rows) > MaxStatsBuckets {
+		return nil, http.StatusBadRequest, errors.New("Too many buckets, use granularity day or a shorter range")
+	}
+
+	stats := SystemStats{
+		Granularity: granularity,
+		From:        from.UTC(),
+		To:          to.UTC(),
+		Buckets:     make([]SystemBucket, 0, len(rows)),
+	}
+	for _, row := range rows {
+		stats.Buckets = append(stats.Buckets, SystemBucket{
+			At:       row.Bucket.UTC(),
+			Stations: row.Stations,
+			Samples:  row.Samples,
+			BikesAvg: row.BikesAvg,
+			DocksAvg: row.DocksAvg,
+			PctEmpty: row.PctEmpty,
+			PctFull:  row.PctFull,
+		})
+	}
+
+	return &stats, http.StatusOK, nil
+}
+
+func validate(granularity string, from time.Time, to time.Time) (string, int, error) {
+	if len(granularity) == 0 {
+		granularity = dbase.RollupHour
+	}
+	if !dbase.ValidRollupGranularity(granularity) {
+		return granularity, http.StatusBadRequest, errors.New("Invalid granularity, use hour or day")
+	}
+	if from.After(to) {
+		return granularity, http.StatusBadRequest, errors.New("from must be before to")
+	}
+	return granularity, http.StatusOK, nil
+}
@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/arthben/BackendGolang/api-gateway/internal/database/dbtest"
+)
+
+func TestStation(t *testing.T) {
+	from := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	scenarios := []struct {
+		name                string
+		rows                int
+		granularity         string
+		from                time.Time
+		to                  time.Time
+		expectedStatus      int
+		expectedGranularity string
+	}{
+		{
+			name:                "Success - Default granularity",
+			rows:                24,
+			from:                from,
+			to:                  to,
+			expectedStatus:      http.StatusOK,
+			expectedGranularity: dbase.RollupHour,
+		},
+		{
+			name:                "Success - Day",
+			rows:                1,
+			granularity:         dbase.RollupDay,
+			from:                from,
+			to:                  to,
+			expectedStatus:      http.StatusOK,
+			expectedGranularity: dbase.RollupDay,
+		},
+		{
+			name:           "Fail - Granularity not valid",
+			granularity:    "week",
+			from:           from,
+			to:             to,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - From after to",
+			from:           to,
+			to:             from,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - Too many buckets",
+			rows:           MaxStatsBuckets + 1,
+			from:           from,
+			to:             to,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Fail - No Data Found",
+			from:           from,
+			to:             to,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			db := &dbtest.FakeDB{Rollups: ts.rows}
+			s := NewService(db)
+
+			stats, httpCode, _ := s.Station(3005, ts.granularity, ts.from, ts.to)
+			if httpCode != ts.expectedStatus {
+				t.Fatalf("Expected status %d but got response %d", ts.expectedStatus, httpCode)
+			}
+			if httpCode != http.StatusOK {
+				return
+			}
+
+			if db.Granularity != ts.expectedGranularity || stats.Granularity != ts.expectedGranularity {
+				t.Errorf("Expected granularity %s but got %s", ts.expectedGranularity, stats.Granularity)
+			}
+			if len(stats.Buckets) != ts.rows || stats.KioskID != 3005 {
+				t.Errorf("Expected %d buckets of kiosk 3005 but got %d of kiosk %d", ts.rows, len(stats.Buckets), stats.KioskID)
+			}
+
+			_, httpCode, _ = s.System(ts.granularity, ts.from, ts.to)
+			if httpCode != ts.expectedStatus {
+				t.Errorf("Expected system status %d but got response %d", ts.expectedStatus, httpCode)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -9,8 +11,10 @@ import (
 	"time"
 
 	"github.com/arthben/BackendGolang/api-gateway/api/handlers"
+	"github.com/arthben/BackendGolang/api-gateway/api/ingest"
 	"github.com/arthben/BackendGolang/api-gateway/internal/config"
 	"github.com/arthben/BackendGolang/api-gateway/internal/database"
+	"github.com/arthben/BackendGolang/api-gateway/internal/scheduler"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -38,8 +42,29 @@ func main() {
 
 	defer dbPool.Close()
 
+	// subcommand, rebuild normalized rows from stored raw payloads
+	if len(os.Args) > 1 && os.Args[1] == "reprocess" {
+		if err := reprocess(dbPool, cfg, os.Args[2:]); err != nil {
+			fmt.Printf("%s\n", err)
+			log.Error().Err(err).Msg("reprocess")
+		}
+		return
+	}
+
+	// subcommand, rebuild the station rollups and flows from stored snapshots
+	if len(os.Args) > 1 && os.Args[1] == "rollup" {
+		if err := rollup(dbPool, os.Args[2:]); err != nil {
+			fmt.Printf("%s\n", err)
+			log.Error().Err(err).Msg("rollup")
+		}
+		return
+	}
+
 	// init request handler
-	handler, err := handlers.NewHandler(dbPool, cfg).BuildHandler()
+	h := handlers.NewHandler(dbPool, cfg)
+	defer h.Close()
+
+	handler, err := h.BuildHandler()
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return
@@ -62,15 +87,55 @@ func main() {
 		log.Warn().Str("SERVER CLOSE", "error : "+server.ListenAndServe().Error())
 	}()
 
+	// init ingestion scheduler
+	ingestion := newScheduler(h.Ingest(), cfg)
+	ingestion.Start(context.Background())
+
 	// wait until server closed
 	select {
 	case <-osSignal:
 		log.Warn().Msg("Server Closed Interrupted by OS")
 	}
 
+	// stop scheduling new runs and wait for the running one
+	ingestion.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.App.WaitTimeOut)*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("server.Shutdown")
+	}
+
 	log.Info().Msg("SERVER STOP")
 }
 
+func newScheduler(service *ingest.Service, cfg *config.EnvParams) *scheduler.Scheduler {
+	return scheduler.New(
+		time.Duration(cfg.Scheduler.Jitter)*time.Second,
+		scheduler.Job{
+			Name:     "rideindego",
+			Interval: time.Duration(cfg.Scheduler.IndegoInterval) * time.Second,
+			Task: func() (string, int, error) {
+				return sourceTask(service.FetchAndStoreIndego())
+			},
+		},
+		scheduler.Job{
+			Name:     "openweather",
+			Interval: time.Duration(cfg.Scheduler.WeatherInterval) * time.Second,
+			Task: func() (string, int, error) {
+				return sourceTask(service.FetchAndStoreWeather())
+			},
+		},
+	)
+}
+
+func sourceTask(result ingest.SourceResult) (string, int, error) {
+	if len(result.Error) > 0 {
+		return result.Status, result.HTTPCode, errors.New(result.Error)
+	}
+	return result.Status, result.HTTPCode, nil
+}
+
 func initLogger() {
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	zerolog.TimeFieldFormat = time.RFC3339
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/api/analytics"
+	"github.com/arthben/BackendGolang/api-gateway/api/openweather"
+	"github.com/arthben/BackendGolang/api-gateway/api/rideindego"
+	"github.com/arthben/BackendGolang/api-gateway/api/stats"
+	"github.com/arthben/BackendGolang/api-gateway/internal/config"
+	"github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+// reprocess rebuild the normalized rows of the snapshots between from and to
+// from their stored raw payloads, one transaction per snapshot. The station
+// rollups and flows of the range are rebuilt at the end.
+//
+//	api-gateway reprocess -from 2024-11-01T00:00:00Z -to 2024-11-30T00:00:00Z [-source rideindego] [-dry-run]
+func reprocess(db database.DBService, cfg *config.EnvParams, args []string) error {
+	flags := flag.NewFlagSet("reprocess", flag.ContinueOnError)
+	from := flags.String("from", "", "start of the range, ex: 2024-11-01T00:00:00Z")
+	to := flags.String("to", "", "end of the range, ex: 2024-11-30T00:00:00Z")
+	source := flags.String("source", "", "rideindego or openweather, default all")
+	dryRun := flags.Bool("dry-run", false, "print the difference without replacing rows")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	fromTime, err := time.Parse(time.RFC3339, *from)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	toTime, err := time.Parse(time.RFC3339, *to)
+	if err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+
+	reprocessors := map[string]func(string, bool) ([]string, error){
+		database.RawSourceRideIndego:  rideindego.NewService(db).Reprocess,
+		database.RawSourceOpenWeather: openweather.NewService(db, cfg).Reprocess,
+	}
+	if _, ok := reprocessors[*source]; !ok && len(*source) > 0 {
+		return fmt.Errorf("invalid -source: %s", *source)
+	}
+
+	raws, err := db.ListRawPayloads(context.Background(), *source, fromTime, toTime)
+	if err != nil {
+		return err
+	}
+
+	var changed, failed int
+	for i, raw := range raws {
+		progress := fmt.Sprintf("[%d/%d] %s %s", i+1, len(raws), raw.Source, raw.FetchID)
+
+		changes, err := reprocessors[raw.Source](raw.FetchID, *dryRun)
+		if err != nil {
+			failed++
+			fmt.Printf("%s: failed - %v\n", progress, err)
+			continue
+		}
+
+		if len(changes) > 0 {
+			changed++
+		}
+		fmt.Printf("%s: %d changes\n", progress, len(changes))
+		if *dryRun {
+			for _, change := range changes {
+				fmt.Printf("    %s\n", change)
+			}
+		}
+	}
+
+	// the flow of the snapshot following a reprocessed one depends on it
+	if !*dryRun && *source != database.RawSourceOpenWeather {
+		if err := stats.NewService(db).Refresh(fromTime, toTime); err != nil {
+			return fmt.Errorf("refresh rollups: %w", err)
+		}
+		if err := analytics.NewService(db).RefreshFlows(fromTime, toTime.Add(database.FlowMaxGap)); err != nil {
+			return fmt.Errorf("refresh flows: %w", err)
+		}
+	}
+
+	fmt.Printf("done: %d snapshots, %d changed, %d failed (dry-run: %v)\n", len(raws), changed, failed, *dryRun)
+	if failed > 0 {
+		return fmt.Errorf("%d snapshots failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/api/analytics"
+	"github.com/arthben/BackendGolang/api-gateway/api/stats"
+	"github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+// rollup rebuild the hourly and daily station rollups and the station
+// flows of the snapshots between from and to, day by day.
+//
+//	api-gateway rollup -from 2024-11-01T00:00:00Z -to 2024-11-30T00:00:00Z
+func rollup(db database.DBService, args []string) error {
+	flags := flag.NewFlagSet("rollup", flag.ContinueOnError)
+	from := flags.String("from", "", "start of the range, ex: 2024-11-01T00:00:00Z")
+	to := flags.String("to", "", "end of the range, ex: 2024-11-30T00:00:00Z")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	fromTime, err := time.Parse(time.RFC3339, *from)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	toTime, err := time.Parse(time.RFC3339, *to)
+	if err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+	if fromTime.After(toTime) {
+		return fmt.Errorf("-from must be before -to")
+	}
+
+	rollups, flows := stats.NewService(db), analytics.NewService(db)
+	for day := database.RollupBucket(database.RollupDay, fromTime); !day.After(toTime); day = day.AddDate(0, 0, 1) {
+		// the last hour of the day, so only the buckets of this day are rebuilt.
+		// Days of a daylight saving change have 23 or 25 hours
+		last := day.AddDate(0, 0, 1).Add(-time.Hour)
+		if err := rollups.Refresh(day, last); err != nil {
+			return fmt.Errorf("%s: %w", day.Format(time.DateOnly), err)
+		}
+		if err := flows.RefreshFlows(day, last); err != nil {
+			return fmt.Errorf("%s: %w", day.Format(time.DateOnly), err)
+		}
+		fmt.Printf("%s: done\n", day.Format(time.DateOnly))
+	}
+
+	return nil
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/api/analytics"
+	"github.com/arthben/BackendGolang/api-gateway/api/rideindego"
+	"github.com/arthben/BackendGolang/api-gateway/api/stats"
+	"github.com/arthben/BackendGolang/api-gateway/internal/client"
+	"github.com/arthben/BackendGolang/api-gateway/internal/config"
+	"github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+const (
+	stateFileName = ".indego-import.state"
+	// stateStored marks in the state file a stored snapshot whose rollups
+	// and flows are not refreshed yet, stateRefreshed the refresh of all
+	// the snapshots marked before
+	stateStored    = "# stored "
+	stateRefreshed = "# refreshed"
+)
+
+// indego-import store archived Indego GeoJSON payloads from a directory.
+// Every imported file is recorded in the state file, so the next run after
+// a crash continue from the last imported file. Snapshots with last_updated
+// already stored are reported as unchanged. The station rollups and flows
+// of the imported range, and of the range a previous run did not refresh,
+// are rebuilt at the end.
+func main() {
+	dir := flag.String("dir", "", "directory of archived GeoJSON files (plain or gzipped)")
+	statePath := flag.String("state", "", "state file to resume import (default <dir>/"+stateFileName+")")
+	flag.Parse()
+
+	if len(*dir) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if len(*statePath) == 0 {
+		*statePath = filepath.Join(*dir, stateFileName)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+
+	dbPool, err := database.NewPool(cfg)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	defer dbPool.Close()
+
+	refresh := func(from time.Time, to time.Time) error {
+		if err := stats.NewService(dbPool).Refresh(from, to); err != nil {
+			return err
+		}
+		// the flow of the snapshot following an imported one depends on it
+		return analytics.NewService(dbPool).RefreshFlows(from, to.Add(database.FlowMaxGap))
+	}
+
+	failed, err := run(rideindego.NewService(dbPool), refresh, *dir, *statePath)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// run import the files of dir, refresh is called once with the range of
+// the stored snapshots not refreshed yet
+func run(
+	service *rideindego.Service,
+	refresh func(from, to time.Time) error,
+	dir string,
+	statePath string,
+) (int, error) {
+	files, err := listFiles(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	state, err := readState(statePath)
+	if err != nil {
+		return 0, err
+	}
+
+	stateFile, err := os.OpenFile(statePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer stateFile.Close()
+
+	var stored, unchanged, skipped, failed int
+	for i, file := range files {
+		progress := fmt.Sprintf("[%d/%d] %s", i+1, len(files), file)
+
+		if state.done[file] {
+			skipped++
+			fmt.Printf("%s: skipped\n", progress)
+			continue
+		}
+
+		snapshot, rawData, err := decodeFile(filepath.Join(dir, file))
+		if err != nil {
+			failed++
+			fmt.Printf("%s: failed - %v\n", progress, err)
+			continue
+		}
+
+		result, err := service.Store(rawData)
+		if err != nil {
+			failed++
+			fmt.Printf("%s: failed - %v\n", progress, err)
+			continue
+		}
+
+		// the snapshot is marked before the file, a crash in between only
+		// import the file again as unchanged
+		line := file + "\n"
+		if result.Status == rideindego.StatusStored {
+			stored++
+			state.addPending(result.LastUpdate)
+			line = stateStored + result.LastUpdate.UTC().Format(time.RFC3339Nano) + "\n" + line
+		} else {
+			unchanged++
+		}
+		fmt.Printf("%s: %s (last_updated %s)\n", progress, result.Status, snapshot.LastUpdated.UTC())
+
+		if _, err := fmt.Fprint(stateFile, line); err != nil {
+			return failed, err
+		}
+	}
+
+	if !state.pendingFrom.IsZero() {
+		if err := refresh(state.pendingFrom, state.pendingTo); err != nil {
+			return failed, fmt.Errorf("refresh rollups and flows: %w", err)
+		}
+		if _, err := fmt.Fprintln(stateFile, stateRefreshed); err != nil {
+			return failed, err
+		}
+		fmt.Printf("rollups and flows refreshed from %s to %s\n", state.pendingFrom.UTC(), state.pendingTo.UTC())
+	}
+
+	fmt.Printf("done: %d stored, %d unchanged, %d skipped, %d failed\n", stored, unchanged, skipped, failed)
+	return failed, nil
+}
+
+// listFiles returns path of *.json and *.geojson files (optionally gzipped)
+// relative to dir, sorted by name
+func listFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		ext := filepath.Ext(strings.TrimSuffix(d.Name(), ".gz"))
+		if ext != ".json" && ext != ".geojson" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+
+	sort.Strings(files)
+	return files, err
+}
+
+// importState is the state file of the previous runs, pendingFrom and
+// pendingTo the range of the stored snapshots not refreshed yet
+type importState struct {
+	done                   map[string]bool
+	pendingFrom, pendingTo time.Time
+}
+
+func (s *importState) addPending(at time.Time) {
+	if s.pendingFrom.IsZero() || at.Before(s.pendingFrom) {
+		s.pendingFrom = at
+	}
+	if at.After(s.pendingTo) {
+		s.pendingTo = at
+	}
+}
+
+func readState(statePath string) (*importState, error) {
+	state := importState{done: make(map[string]bool)}
+
+	f, err := os.Open(statePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case len(line) == 0:
+		case line == stateRefreshed:
+			state.pendingFrom, state.pendingTo = time.Time{}, time.Time{}
+		case strings.HasPrefix(line, stateStored):
+			at, err := time.Parse(time.RFC3339Nano, strings.TrimPrefix(line, stateStored))
+			if err != nil {
+				return nil, fmt.Errorf("invalid state line %q: %w", line, err)
+			}
+			state.addPending(at)
+		default:
+			state.done[line] = true
+		}
+	}
+
+	return &state, scanner.Err()
+}
+
+// decodeFile returns the decoded snapshot and the uncompressed file content
+func decodeFile(path string) (*rideindego.FetchResponse, []byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// detect gzip by the magic number, file name is not reliable
+	if bytes.HasPrefix(raw, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, nil, err
+		}
+		defer gz.Close()
+
+		if raw, err = io.ReadAll(gz); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var snapshot rideindego.FetchResponse
+	if err := client.GetJSON(raw, &snapshot); err != nil {
+		return nil, nil, err
+	}
+	if snapshot.LastUpdated.IsZero() {
+		return nil, nil, errors.New("missing last_updated")
+	}
+
+	return &snapshot, raw, nil
+}
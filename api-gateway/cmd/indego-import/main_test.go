@@ -0,0 +1,108 @@
+package main
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const sample = `{"type":"FeatureCollection","features":[],"last_updated":"2024-11-08T01:00:00.000Z"}`
+
+func writeSamples(t *testing.T) string {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "b.json"), []byte(sample), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("skip me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "2024"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "2024", "a.json.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(sample))
+	gz.Close()
+	f.Close()
+
+	return dir
+}
+
+func TestListFiles(t *testing.T) {
+	dir := writeSamples(t)
+
+	files, err := listFiles(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, but error occur %s", err)
+	}
+
+	expected := []string{filepath.Join("2024", "a.json.gz"), "b.json"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected files %v but got %v", expected, files)
+	}
+}
+
+func TestDecodeFile(t *testing.T) {
+	dir := writeSamples(t)
+	expected := time.Date(2024, 11, 8, 1, 0, 0, 0, time.UTC)
+
+	for _, file := range []string{"b.json", filepath.Join("2024", "a.json.gz")} {
+		t.Run(file, func(t *testing.T) {
+			snapshot, rawData, err := decodeFile(filepath.Join(dir, file))
+			if err != nil {
+				t.Fatalf("Expected no error, but error occur %s", err)
+			}
+
+			if string(rawData) != sample {
+				t.Errorf("Expected uncompressed content %s but got %s", sample, rawData)
+			}
+
+			if !snapshot.LastUpdated.Equal(expected) {
+				t.Errorf("Expected last_updated %v but got %v", expected, snapshot.LastUpdated)
+			}
+		})
+	}
+
+	if _, _, err := decodeFile(filepath.Join(dir, "notes.txt")); err == nil {
+		t.Errorf("Expected error on invalid GeoJSON")
+	}
+}
+
+func TestReadState(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), stateFileName)
+
+	state, err := readState(statePath)
+	if err != nil || len(state.done) != 0 || !state.pendingFrom.IsZero() {
+		t.Fatalf("Expected empty state, got %v - %v", state, err)
+	}
+
+	// a.json was refreshed, c.json and b.json.gz were stored by a run which
+	// did not refresh them
+	content := stateStored + "2024-11-08T01:00:00Z\na.json\n" + stateRefreshed + "\n\n" +
+		stateStored + "2024-11-08T03:00:00Z\nc.json\n" +
+		stateStored + "2024-11-08T02:00:00Z\nb.json.gz\n"
+	if err := os.WriteFile(statePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = readState(statePath)
+	if err != nil {
+		t.Fatalf("Expected no error, but error occur %s", err)
+	}
+	if !state.done["a.json"] || !state.done["b.json.gz"] || !state.done["c.json"] || len(state.done) != 3 {
+		t.Errorf("Expected 3 imported files but got %v", state.done)
+	}
+
+	from, to := time.Date(2024, 11, 8, 2, 0, 0, 0, time.UTC), time.Date(2024, 11, 8, 3, 0, 0, 0, time.UTC)
+	if !state.pendingFrom.Equal(from) || !state.pendingTo.Equal(to) {
+		t.Errorf("Expected pending refresh from %v to %v but got %v to %v", from, to, state.pendingFrom, state.pendingTo)
+	}
+}
@@ -15,16 +15,721 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/analytics/batteries": {
+            "get": {
+                "description": "## Battery levels of the electric bikes on one snapshot\n\nBattery level of every docked electric bike, from the bikes stored with each snapshot. Without ` + "`" + `at` + "`" + ` the latest snapshot is used, otherwise the snapshot is selected by ` + "`" + `at` + "`" + `, ` + "`" + `mode` + "`" + ` and ` + "`" + `maxAge` + "`" + ` as for ` + "`" + `/stations` + "`" + `. ` + "`" + `kioskId` + "`" + ` limits the result to one station.\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/analytics/batteries?at=2024-11-08T17:00:00Z\u0026mode=before\n` + "`" + `` + "`" + `` + "`" + `\n\nBikes with battery below ` + "`" + `BATTERY_LOW_THRESHOLD` + "`" + ` percent (default 20) are counted in ` + "`" + `lowBattery` + "`" + `. Bikes without battery level, or with a level out of 0 to 100, are not counted.\n\n- ` + "`" + `distribution` + "`" + ` electric bikes of all stations by band of battery level\n- ` + "`" + `stations` + "`" + ` electric bikes, low battery bikes, average battery and distribution of each station, the most low battery bikes first\n- ` + "`" + `trend` + "`" + ` the totals of the snapshot\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  from: '2024-11-08T16:59:12Z',\n  to: '2024-11-08T16:59:12Z',\n  snapshots: 1,\n  lowThreshold: 20,\n  electricBikes: 412,\n  lowBattery: 37,\n  avgBattery: 61.4,\n  distribution: [\n    { band: '0-19', bikes: 37 },\n    { band: '20-39', bikes: 58 },\n    { band: '40-59', bikes: 81 },\n    { band: '60-79', bikes: 104 },\n    { band: '80-100', bikes: 132 }\n  ],\n  stations: [\n    {\n      kioskId: 3005,\n      name: 'Welcome Park, NPS',\n      electricBikes: 4,\n      lowBattery: 2,\n      avgBattery: 31.5,\n      distribution: [ { band: '0-19', bikes: 2 } ]\n    }\n  ],\n  trend: [\n    { at: '2024-11-08T16:59:12Z', electricBikes: 412, lowBattery: 37, avgBattery: 61.4 }\n  ]\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                      |\n|------|--------------------------------------------------|\n| 200  | Batteries found                                  |\n| 400  | Invalid kioskId, timestamp, mode or maxAge       |\n| 401  | Bad Authorization. Check token                   |\n| 404  | Data Not Found                                   |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Battery levels of the electric bikes on one snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-08T17:00:00Z",
+                        "name": "at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "after, before or nearest",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2h",
+                        "name": "maxAge",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 3005",
+                        "name": "kioskId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/analytics/batteries/trend": {
+            "get": {
+                "description": "## Battery levels of the electric bikes over a time range\n\nSame as ` + "`" + `/analytics/batteries` + "`" + ` for all the snapshots between ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + `, to plan the battery swaps:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/analytics/batteries/trend?from=2024-11-01T00:00:00Z\u0026to=2024-11-08T00:00:00Z\u0026kioskId=3005\n` + "`" + `` + "`" + `` + "`" + `\n\nThe range is at most 31 days. The counts of ` + "`" + `distribution` + "`" + ` and ` + "`" + `stations` + "`" + ` are averaged over the ` + "`" + `snapshots` + "`" + ` of the range, a station without electric bikes on a snapshot counts zero. ` + "`" + `trend` + "`" + ` has the totals of every snapshot, oldest first.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  kioskId: 3005,\n  from: '2024-11-01T00:00:00Z',\n  to: '2024-11-08T00:00:00Z',\n  snapshots: 168,\n  lowThreshold: 20,\n  electricBikes: 3.52,\n  lowBattery: 0.87,\n  avgBattery: 54.12,\n  distribution: [\n    { band: '0-19', bikes: 0.87 }\n  ],\n  stations: [\n    {\n      kioskId: 3005,\n      name: 'Welcome Park, NPS',\n      electricBikes: 3.52,\n      lowBattery: 0.87,\n      avgBattery: 54.12,\n      distribution: [ { band: '0-19', bikes: 0.87 } ]\n    }\n  ],\n  trend: [\n    { at: '2024-11-01T00:59:08Z', electricBikes: 4, lowBattery: 1, avgBattery: 48.25 }\n  ]\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                      |\n|------|--------------------------------------------------|\n| 200  | Batteries found                                  |\n| 400  | Invalid kioskId, timestamp or range too long     |\n| 401  | Bad Authorization. Check token                   |\n| 404  | Data Not Found                                   |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Battery levels of the electric bikes over a time range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-01T00:00:00Z",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-08T00:00:00Z",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 3005",
+                        "name": "kioskId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/analytics/flows": {
+            "get": {
+                "description": "## Bike departures and arrivals inferred from the snapshots\n\nBikes have no ID in the Indego feed, the flows are inferred from the change of ` + "`" + `bikesAvailable` + "`" + ` between two consecutive snapshots of a station: a drop counts as departures, a rise as arrivals. Snapshots more than 3 hours apart are not compared. Flows are kept per station and per hour (UTC) in table ` + "`" + `station_flows` + "`" + `, refreshed after each stored snapshot.\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/analytics/flows?from=2024-11-01T00:00:00Z\u0026to=2024-11-08T00:00:00Z\u0026kioskId=3005\n` + "`" + `` + "`" + `` + "`" + `\n\nThe range is at most 31 days. Without ` + "`" + `kioskId` + "`" + `, ` + "`" + `intervals` + "`" + ` sum all stations.\n\n- ` + "`" + `intervals` + "`" + ` departures, arrivals and ` + "`" + `net` + "`" + ` (arrivals minus departures) for each hour\n- ` + "`" + `stations` + "`" + ` departures, arrivals and ` + "`" + `net` + "`" + ` of each station over the range, the busiest first\n- ` + "`" + `busiestHours` + "`" + ` the 5 hours of the day in ` + "`" + `timeZone` + "`" + ` (Philadelphia, ` + "`" + `America/New_York` + "`" + `) with the most departures and arrivals of all stations, whatever ` + "`" + `kioskId` + "`" + `\n\nA net flow is a lower bound of the trips, a bike which left and another which returned between two snapshots are not seen.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  kioskId: 3005,\n  from: '2024-11-01T00:00:00Z',\n  to: '2024-11-08T00:00:00Z',\n  departures: 214,\n  arrivals: 198,\n  intervals: [\n    { at: '2024-11-01T12:00:00Z', departures: 5, arrivals: 2, net: -3 }\n  ],\n  stations: [\n    { kioskId: 3005, departures: 214, arrivals: 198, net: -16 }\n  ],\n  busiestHours: [\n    { hour: 17, departures: 1840, arrivals: 1795 }\n  ],\n  timeZone: 'America/New_York'\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                      |\n|------|--------------------------------------------------|\n| 200  | Flows found                                      |\n| 400  | Invalid kioskId, timestamp or range too long     |\n| 401  | Bad Authorization. Check token                   |\n| 404  | Data Not Found                                   |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Bike departures and arrivals inferred from the snapshots",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-01T00:00:00Z",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-08T00:00:00Z",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 3005",
+                        "name": "kioskId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/analytics/utilization": {
+            "get": {
+                "description": "## Stations ranked by time empty, time full or turnover\n\nUtilization of every station over the snapshots between ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + `, at most 31 days:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/analytics/utilization?from=2024-11-01T00:00:00Z\u0026to=2024-11-08T00:00:00Z\u0026rankBy=empty\u0026top=20\n` + "`" + `` + "`" + `` + "`" + `\n\n- ` + "`" + `pctEmpty` + "`" + ` percent of snapshots without bike\n- ` + "`" + `pctFull` + "`" + ` percent of snapshots without free dock\n- ` + "`" + `turnover` + "`" + ` average absolute change of ` + "`" + `bikesAvailable` + "`" + ` between two consecutive snapshots of the station\n\n` + "`" + `rankBy` + "`" + ` is ` + "`" + `empty` + "`" + ` (default), ` + "`" + `full` + "`" + ` or ` + "`" + `turnover` + "`" + `, the highest first. ` + "`" + `top` + "`" + ` is the number of stations returned, default 20, at most 500. ` + "`" + `total` + "`" + ` is the number of stations ranked. Add ` + "`" + `zip` + "`" + ` or ` + "`" + `kioskType` + "`" + ` to rank only the stations of one zip code or one kiosk type.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  from: '2024-11-01T00:00:00Z',\n  to: '2024-11-08T00:00:00Z',\n  rankBy: 'empty',\n  total: 262,\n  stations: [\n    {\n      rank: 1,\n      kioskId: 3005,\n      name: 'Welcome Park, NPS',\n      zip: '19106',\n      kioskType: 1,\n      samples: 2016,\n      pctEmpty: 38.4,\n      pctFull: 2.1,\n      turnover: 0.82\n    }\n  ]\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                                    |\n|------|----------------------------------------------------------------|\n| 200  | Utilization found                                              |\n| 400  | Invalid timestamp, rankBy, top, kioskType or range too long    |\n| 401  | Bad Authorization. Check token                                 |\n| 404  | Data Not Found                                                 |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Stations ranked by time empty, time full or turnover",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-01T00:00:00Z",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-08T00:00:00Z",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "empty, full or turnover",
+                        "name": "rankBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 20",
+                        "name": "top",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 19103",
+                        "name": "zip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 1",
+                        "name": "kioskType",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/analytics/weather-impact": {
+            "get": {
+                "description": "## Availability and turnover by weather\n\nSnapshots between ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` (at most 31 days) grouped by the weather at the time of the snapshot, for one station with ` + "`" + `kioskId` + "`" + ` or for all stations without it:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/analytics/weather-impact?kioskId=3005\u0026from=2024-11-01T00:00:00Z\u0026to=2024-11-08T00:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\nThe weather of a snapshot is the observation fetched on the same run, or the nearest observation within one hour. Snapshots without weather are left out. Each dimension is banded on its own:\n\n- ` + "`" + `temperature` + "`" + ` bands of 5 °C, ex: ` + "`" + `5..10` + "`" + `\n- ` + "`" + `rain` + "`" + ` from ` + "`" + `rain_one_hour` + "`" + `: ` + "`" + `none` + "`" + `, ` + "`" + `light` + "`" + ` (below 2.5 mm/h), ` + "`" + `moderate` + "`" + ` (below 7.6 mm/h) or ` + "`" + `heavy` + "`" + `\n- ` + "`" + `wind` + "`" + ` from the wind speed: ` + "`" + `light` + "`" + ` (below 3.4 m/s), ` + "`" + `moderate` + "`" + ` (below 8 m/s), ` + "`" + `strong` + "`" + ` (below 13.9 m/s) or ` + "`" + `gale` + "`" + `, ` + "`" + `unknown` + "`" + ` when the observation has no wind speed\n- ` + "`" + `condition` + "`" + ` the weather ` + "`" + `main` + "`" + `, ex: ` + "`" + `Clear` + "`" + `, ` + "`" + `Rain` + "`" + `, ` + "`" + `Snow` + "`" + `\n\nFor each band ` + "`" + `bikesAvg` + "`" + ` is the mean of ` + "`" + `bikesAvailable` + "`" + `, ` + "`" + `turnover` + "`" + ` the average absolute change of ` + "`" + `bikesAvailable` + "`" + ` since the previous snapshot of the station and ` + "`" + `samples` + "`" + ` the number of station snapshots.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  kioskId: 3005,\n  from: '2024-11-01T00:00:00Z',\n  to: '2024-11-08T00:00:00Z',\n  temperature: [ { band: '5..10', samples: 812, bikesAvg: 4.86, turnover: 1.14 } ],\n  rain: [\n    { band: 'none', samples: 700, bikesAvg: 4.2, turnover: 1.31 },\n    { band: 'light', samples: 112, bikesAvg: 7.1, turnover: 0.42 }\n  ],\n  wind: [ { band: 'light', samples: 812, bikesAvg: 4.86, turnover: 1.14 } ],\n  condition: [\n    { band: 'Clear', samples: 700, bikesAvg: 4.2, turnover: 1.31 },\n    { band: 'Rain', samples: 112, bikesAvg: 7.1, turnover: 0.42 }\n  ]\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                      |\n|------|--------------------------------------------------|\n| 200  | Weather impact found                             |\n| 400  | Invalid kioskId, timestamp or range too long     |\n| 401  | Bad Authorization. Check token                   |\n| 404  | Data Not Found                                   |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Availability and turnover by weather",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 3005",
+                        "name": "kioskId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-01T00:00:00Z",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-08T00:00:00Z",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/anomalies": {
+            "get": {
+                "description": "## Stuck, offline and unavailable stations\n\nAfter each stored snapshot the stations are checked for anomalies, kept with their period in table ` + "`" + `station_anomalies` + "`" + `:\n\n- ` + "`" + `stuck` + "`" + ` bikes and docks unchanged for ` + "`" + `ANOMALY_STUCK_SNAPSHOTS` + "`" + ` consecutive snapshots (default 24)\n- ` + "`" + `offline` + "`" + ` ` + "`" + `kioskConnectionStatus` + "`" + ` is not ` + "`" + `Active` + "`" + `\n- ` + "`" + `unavailable` + "`" + ` ` + "`" + `kioskStatus` + "`" + ` or ` + "`" + `kioskPublicStatus` + "`" + ` is ` + "`" + `Unavailable` + "`" + `\n\nAn anomaly is opened at the first snapshot of the period and closed at the first snapshot without it. A station missing on a snapshot keeps its anomalies open.\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/anomalies?open=true\n` + "`" + `` + "`" + `` + "`" + `\n\n` + "`" + `open=false` + "`" + ` returns the closed anomalies only, ` + "`" + `kind` + "`" + ` and ` + "`" + `kioskId` + "`" + ` filter the anomalies. At most 1000 anomalies are returned, the last opened first.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  anomalies: [\n    {\n      anomalyId: 42,\n      kioskId: 3005,\n      name: 'Welcome Park, NPS',\n      kind: 'stuck',\n      detail: 'bikesAvailable 3 and docksAvailable 12 unchanged',\n      open: true,\n      openedAt: '2024-11-07T09:00:00Z',\n      lastSeenAt: '2024-11-08T12:00:00Z'\n    }\n  ]\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                      |\n|------|--------------------------------------------------|\n| 200  | Anomalies found                                  |\n| 400  | Invalid open, kind or kioskId                    |\n| 401  | Bad Authorization. Check token                   |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Stuck, offline and unavailable stations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "ex: true",
+                        "name": "open",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "stuck, offline or unavailable",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 3005",
+                        "name": "kioskId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
         "/api/v1/indego-data-fetch-and-store-it-db": {
             "post": {
-                "description": "## Store data from Indego\n\nAn endpoints which downloads fresh data from [Indego GeoJSON station status API](https://www.rideindego.com/stations/json/) and stores it inside PostgreSQL.\n\n` + "`" + `` + "`" + `` + "`" + `bash\n# this endpoint will be trigger every hour to fetch the data and insert it in the PostgreSQL database\nPOST http://localhost:3000/api/v1/indego-data-fetch-and-store-it-db\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Fetch and store to database is success |\n| 401  | Bad Authorization. Check token         |\n",
+                "description": "## Store data from Indego\n\nAn endpoints which downloads fresh data from [Indego GeoJSON station status API](https://www.rideindego.com/stations/json/) and stores it inside PostgreSQL.\n\n` + "`" + `` + "`" + `` + "`" + `bash\n# this endpoint will be trigger every hour to fetch the data and insert it in the PostgreSQL database\nPOST http://localhost:3000/api/v1/indego-data-fetch-and-store-it-db\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response\nIndego and Open Weather are fetched in parallel, each source is reported separately. ` + "`" + `status` + "`" + ` of a source is ` + "`" + `stored` + "`" + ` when a new row inserted, ` + "`" + `unchanged` + "`" + ` when the same snapshot already stored before, or ` + "`" + `failed` + "`" + `. ` + "`" + `httpCode` + "`" + ` is the upstream HTTP code, also when the store failed after upstream answered ` + "`" + `200` + "`" + `, and ` + "`" + `rows` + "`" + ` is the number of rows written. ` + "`" + `rawFetchId` + "`" + ` is the ` + "`" + `fetchId` + "`" + ` of the archived upstream body on ` + "`" + `GET /api/v1/snapshots/{fetchId}/raw` + "`" + `.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  status: 'Fetch and store partial success',\n  rideindego: { status: 'stored', httpCode: 200, rows: 1123, durationMs: 812, rawFetchId: '5d2c7a1e-8f3b-4c6d-9e2a-1b7f4c3d2e10' },\n  openweather: { status: 'failed', httpCode: 401, rows: 0, durationMs: 140, error: 'Unexpected status code: 401' }\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Asynchronous\nWith ` + "`" + `?async=true` + "`" + ` the request returns ` + "`" + `202 Accepted` + "`" + ` right away with the job ID, the job status is available on ` + "`" + `GET /api/v1/jobs/{jobId}` + "`" + `.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  jobId: '0b6c9a4e-2f4d-4d8e-9a53-8d1f0c1f6a01',\n  status: 'queued',\n  createdAt: '2024-11-08T01:00:00Z'\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Fetch and store to database is success |\n| 202  | Job accepted (async)                   |\n| 207  | Only one of the sources failed         |\n| 401  | Bad Authorization. Check token         |\n| 502  | All sources failed                     |\n| 503  | Too many queued jobs (async)           |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Store data from Indego",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "ex: true",
+                        "name": "async",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/ingestion-runs": {
+            "get": {
+                "description": "## History of fetch and store runs\n\nEvery fetch and store of a source (manual, scheduled or asynchronous job) is recorded in table ` + "`" + `ingestion_runs` + "`" + `.\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/ingestion-runs?source=rideindego\u0026from=2024-11-08T00:00:00Z\u0026to=2024-11-09T00:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\nAll parameters are optional. ` + "`" + `source` + "`" + ` is ` + "`" + `rideindego` + "`" + ` or ` + "`" + `openweather` + "`" + `, empty means all sources. ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` filter on the start time of the run, the default is the last 24 hours before ` + "`" + `to` + "`" + ` (default now). Runs are sorted newest first, at most 1000 runs are returned.\n\n` + "`" + `outcome` + "`" + ` is ` + "`" + `stored` + "`" + `, ` + "`" + `unchanged` + "`" + ` or ` + "`" + `failed` + "`" + `. ` + "`" + `upstreamStatus` + "`" + ` is the HTTP status of the upstream API, ` + "`" + `0` + "`" + ` when the upstream could not be reached. The row counts are the rows written by the run.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  from: '2024-11-08T00:00:00Z',\n  to: '2024-11-09T00:00:00Z',\n  runs: [\n    {\n      runId: '5b0f7c1e-93a4-4b1f-9d55-1b2c3d4e5f60',\n      source: 'rideindego',\n      startedAt: '2024-11-08T01:00:00Z',\n      finishedAt: '2024-11-08T01:00:01Z',\n      durationMs: 812,\n      outcome: 'stored',\n      upstreamStatus: 200,\n      fetchId: '0b6c9a4e-2f4d-4d8e-9a53-8d1f0c1f6a01',\n      features: 150,\n      properties: 150,\n      bikes: 822,\n      rows: 1123\n    }\n  ]\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Runs found, may be empty               |\n| 400  | Invalid source or timestamp format     |\n| 401  | Bad Authorization. Check token         |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "History of fetch and store runs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: rideindego",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-08T00:00:00Z",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-09T00:00:00Z",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/ingestion-runs/latest": {
+            "get": {
+                "description": "## Latest fetch and store run of each source\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/ingestion-runs/latest\n` + "`" + `` + "`" + `` + "`" + `\n\nFor each source, ` + "`" + `latest` + "`" + ` is the last run whatever the outcome and ` + "`" + `lastSuccess` + "`" + ` is the last run with outcome ` + "`" + `stored` + "`" + ` or ` + "`" + `unchanged` + "`" + ` (` + "`" + `null` + "`" + ` when it never succeeded). The fields of a run are described on ` + "`" + `GET /api/v1/ingestion-runs` + "`" + `.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  rideindego: {\n    latest: { runId: '...', source: 'rideindego', outcome: 'failed', upstreamStatus: 503, error: 'Unexpected status code: 503', ... },\n    lastSuccess: { runId: '...', source: 'rideindego', outcome: 'stored', features: 150, properties: 150, bikes: 822, ... }\n  },\n  openweather: {\n    latest: { ... },\n    lastSuccess: { ... }\n  }\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Latest runs found                      |\n| 401  | Bad Authorization. Check token         |\n| 404  | No run recorded yet                    |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Latest fetch and store run of each source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/jobs/{jobId}": {
+            "get": {
+                "description": "## Status of an asynchronous fetch and store\n\nJob created by ` + "`" + `POST /api/v1/indego-data-fetch-and-store-it-db?async=true` + "`" + `.\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/jobs/{jobId}\n` + "`" + `` + "`" + `` + "`" + `\n\n` + "`" + `status` + "`" + ` is one of ` + "`" + `queued` + "`" + `, ` + "`" + `running` + "`" + `, ` + "`" + `succeeded` + "`" + `, ` + "`" + `partial` + "`" + ` or ` + "`" + `failed` + "`" + `. A job is ` + "`" + `partial` + "`" + ` when only one of the sources failed, the result of each source tells which one. The result of each source is available when the job finished. Jobs which were running while the server restarted are marked as ` + "`" + `failed` + "`" + `.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  jobId: '0b6c9a4e-2f4d-4d8e-9a53-8d1f0c1f6a01',\n  status: 'succeeded',\n  createdAt: '2024-11-08T01:00:00Z',\n  startedAt: '2024-11-08T01:00:00Z',\n  finishedAt: '2024-11-08T01:00:02Z',\n  rideindego: { status: 'stored', httpCode: 200, rows: 1123, durationMs: 812 },\n  openweather: { status: 'stored', httpCode: 200, rows: 2, durationMs: 140 }\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Job found                              |\n| 400  | Invalid jobId format                   |\n| 401  | Bad Authorization. Check token         |\n| 404  | Data Not Found                         |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Status of an asynchronous fetch and store",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 0b6c9a4e-2f4d-4d8e-9a53-8d1f0c1f6a01",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/snapshots/{fetchId}/raw": {
+            "get": {
+                "description": "## Raw upstream payload of a fetch\n\nThe response body of Indego or Open Weather Map API exactly as it was received, including fields which are not stored in the normalized tables.\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/snapshots/{fetchId}/raw\n` + "`" + `` + "`" + `` + "`" + `\n\n` + "`" + `fetchId` + "`" + ` is the ` + "`" + `rawFetchId` + "`" + ` reported by the fetch, also the ` + "`" + `fetch_id` + "`" + ` of ` + "`" + `rideindego_master` + "`" + ` or ` + "`" + `openweather_master` + "`" + ` when the fetch stored a new snapshot or observation. The response has header ` + "`" + `X-Source` + "`" + ` (` + "`" + `rideindego` + "`" + ` or ` + "`" + `openweather` + "`" + `) and ` + "`" + `X-Fetched-At` + "`" + `.\n\nThe body of every fetch is archived. A fetch reported ` + "`" + `unchanged` + "`" + ` (same Indego ` + "`" + `last_updated` + "`" + `, or same Open Weather ` + "`" + `dt` + "`" + ` and city) stores no snapshot, its body is archived under a ` + "`" + `fetch_id` + "`" + ` of its own.\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Raw payload found                      |\n| 400  | Invalid fetchId format                 |\n| 401  | Bad Authorization. Check token         |\n| 404  | Data Not Found                         |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Raw upstream payload of a fetch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 0b6c9a4e-2f4d-4d8e-9a53-8d1f0c1f6a01",
+                        "name": "fetchId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/stations": {
+            "get": {
+                "description": "## Snapshot of all stations at a specified time\n\nData for all stations as of 11am Universal Coordinated Time on September 1st, 2019:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations?at=2019-09-01T10:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\nThis endpoint should respond as follows, with the actual time of the first snapshot of data on or after the requested time and the data:\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  at: '2019-09-01T10:00:00Z',\n  stations: { /* As per the Indego API */ },\n  weather: { /* As per the Open Weather Map API response for Philadelphia */ }\n}\n` + "`" + `` + "`" + `` + "`" + `\n\nUse ` + "`" + `mode` + "`" + ` to select the snapshot relative to ` + "`" + `at` + "`" + `:\n\n| mode      | Snapshot                                          |\n|-----------|---------------------------------------------------|\n| ` + "`" + `after` + "`" + `   | First snapshot on or after ` + "`" + `at` + "`" + ` (default)         |\n| ` + "`" + `before` + "`" + `  | Last snapshot on or before ` + "`" + `at` + "`" + `                   |\n| ` + "`" + `nearest` + "`" + ` | Snapshot closest to ` + "`" + `at` + "`" + `, the earlier one on ties |\n\n` + "`" + `maxAge` + "`" + ` (ex: ` + "`" + `30m` + "`" + `, ` + "`" + `2h` + "`" + `) is the largest distance allowed between ` + "`" + `at` + "`" + ` and the snapshot. When no snapshot is close enough the response is 404, so a request for 10:00 never returns data from three days later.\n\nThe weather linked to the snapshot is returned when the snapshot was fetched together with the weather, or when the scheduler fetched the weather within one hour after the snapshot. Otherwise the weather is selected with the same ` + "`" + `mode` + "`" + ` and ` + "`" + `maxAge` + "`" + ` relative to the time of the snapshot. Without ` + "`" + `mode` + "`" + ` the latest observation on or before the snapshot is preferred.\n\n### Filter, sort and pagination\n\n| Parameter     | Filter                                     |\n|---------------|--------------------------------------------|\n| ` + "`" + `kioskStatus` + "`" + ` | Exact kiosk status, ex: ` + "`" + `Active` + "`" + `           |\n| ` + "`" + `minBikes` + "`" + `    | At least this number of bikes available    |\n| ` + "`" + `minDocks` + "`" + `    | At least this number of docks available    |\n| ` + "`" + `isVirtual` + "`" + `   | ` + "`" + `true` + "`" + ` or ` + "`" + `false` + "`" + `                          |\n| ` + "`" + `zip` + "`" + `         | Address zip code, ex: ` + "`" + `19103` + "`" + `              |\n| ` + "`" + `kioskType` + "`" + `   | Kiosk type, ex: ` + "`" + `1` + "`" + `                        |\n\n` + "`" + `sort` + "`" + ` is a numeric property of the station, ex: ` + "`" + `bikesAvailable` + "`" + `. Prefix it with ` + "`" + `-` + "`" + ` for descending order, ex: ` + "`" + `sort=-docksAvailable` + "`" + `. Without ` + "`" + `sort` + "`" + ` the stations keep the order of the snapshot.\n\n` + "`" + `limit` + "`" + ` (1 to 500) and ` + "`" + `offset` + "`" + ` select one page of stations. ` + "`" + `page.total` + "`" + ` is the number of stations matching the filter:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations?at=2019-09-01T10:00:00Z\u0026kioskStatus=Active\u0026minBikes=3\u0026sort=-bikesAvailable\u0026limit=20\u0026offset=40\n` + "`" + `` + "`" + `` + "`" + `\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  at: '2019-09-01T10:00:00Z',\n  stations: { /* Only the stations of the page */ },\n  weather: { /* ... */ },\n  page: { total: 87, limit: 20, offset: 40 }\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Several stations\n\n` + "`" + `kioskIds` + "`" + ` (at most 100, comma separated) returns only these stations, all from the same snapshot. ` + "`" + `notFound` + "`" + ` lists the requested IDs which are not in the snapshot, ` + "`" + `notMatched` + "`" + ` the requested IDs which are in the snapshot but excluded by the other filters (ex: ` + "`" + `minBikes` + "`" + `, ` + "`" + `zip` + "`" + `). ` + "`" + `limit` + "`" + ` and ` + "`" + `offset` + "`" + ` can not be used with ` + "`" + `kioskIds` + "`" + `.\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations?kioskIds=3005,3006,3010\u0026at=2019-09-01T10:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  at: '2019-09-01T10:00:00Z',\n  stations: { /* Stations 3005 and 3006 as per the Indego API */ },\n  weather: { /* ... */ },\n  page: { total: 2, limit: 0, offset: 0 },\n  notFound: [3010],\n  notMatched: []\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Fetch and store to database is success |\n| 400  | Invalid Request Parameter              |\n| 401  | Bad Authorization. Check token         |\n| 404  | Data Not Found                         |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Snapshot of one station at a specific time",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2019-09-01T10:00:00Z",
+                        "name": "at",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "after, before or nearest",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2h",
+                        "name": "maxAge",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: FullService",
+                        "name": "kioskStatus",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 3",
+                        "name": "minBikes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 3",
+                        "name": "minDocks",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "ex: false",
+                        "name": "isVirtual",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 19103",
+                        "name": "zip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 1",
+                        "name": "kioskType",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 3005,3006,3010",
+                        "name": "kioskIds",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: -bikesAvailable",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 0",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/stations/diff": {
+            "get": {
+                "description": "## Change of the stations between two snapshots\n\nCompare the snapshot at ` + "`" + `from` + "`" + ` with the snapshot at ` + "`" + `to` + "`" + `. Both snapshots are selected like on ` + "`" + `/api/v1/stations` + "`" + `, ` + "`" + `mode` + "`" + ` and ` + "`" + `maxAge` + "`" + ` apply to both of them:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations/diff?from=2024-11-08T07:00:00Z\u0026to=2024-11-08T09:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\n` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` in the response are the time of the selected snapshots. ` + "`" + `changed` + "`" + ` lists, by ` + "`" + `kioskId` + "`" + `, the stations whose counts or status changed, ` + "`" + `kioskStatus` + "`" + ` is only set on status transition. ` + "`" + `added` + "`" + ` are the stations only in the ` + "`" + `to` + "`" + ` snapshot, ` + "`" + `removed` + "`" + ` the stations only in the ` + "`" + `from` + "`" + ` snapshot.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  from: '2024-11-08T07:00:04Z',\n  to: '2024-11-08T09:00:02Z',\n  changed: [\n    {\n      kioskId: 3005,\n      name: 'Welcome Park, NPS',\n      bikesAvailable: { from: 10, to: 2, delta: -8 },\n      docksAvailable: { from: 5, to: 13, delta: 8 },\n      electricBikesAvailable: { from: 2, to: 0, delta: -2 },\n      kioskStatus: { from: 'FullService', to: 'Unavailable' }\n    }\n  ],\n  added: [ { kioskId: 3010, name: '15th \u0026 Spruce' } ],\n  removed: []\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                      |\n|------|--------------------------------------------------|\n| 200  | Diff found                                       |\n| 400  | Invalid timestamp, mode or from is not before to |\n| 401  | Bad Authorization. Check token                   |\n| 404  | Data Not Found                                   |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Change of the stations between two snapshots",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-08T07:00:00Z",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-08T09:00:00Z",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "after, before or nearest",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2h",
+                        "name": "maxAge",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/stations/latest": {
+            "get": {
+                "description": "## Latest snapshot of all stations\n\nThe most recent snapshot, without ` + "`" + `at` + "`" + `:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations/latest\n` + "`" + `` + "`" + `` + "`" + `\n\nThe snapshot is served from memory and refreshed every time the ingestion stores a new snapshot. ` + "`" + `age` + "`" + ` is the number of seconds since ` + "`" + `last_updated` + "`" + ` of the snapshot. ` + "`" + `stale` + "`" + ` is ` + "`" + `true` + "`" + ` when the age is longer than the Indego ingest interval (` + "`" + `SCHEDULER_INDEGO_INTERVAL` + "`" + ` plus ` + "`" + `SCHEDULER_JITTER` + "`" + `, 1 hour when the scheduler is disabled).\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  at: '2024-11-08T01:00:00Z',\n  age: 312,\n  stale: false,\n  stations: { /* As per the Indego API */ },\n  weather: { /* As per the Open Weather Map API response for Philadelphia */ }\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Latest snapshot found                  |\n| 401  | Bad Authorization. Check token         |\n| 404  | No snapshot stored yet                 |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Latest snapshot of all stations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/stations/nearby": {
+            "get": {
+                "description": "## Stations near a point\n\nStations within ` + "`" + `radius` + "`" + ` meters of the point ` + "`" + `lat` + "`" + `, ` + "`" + `lon` + "`" + `, sorted by distance:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations/nearby?lat=39.9526\u0026lon=-75.1652\u0026radius=800\u0026minBikes=1\n` + "`" + `` + "`" + `` + "`" + `\n\n| Parameter  | Description                                                              |\n|------------|--------------------------------------------------------------------------|\n| ` + "`" + `lat` + "`" + `      | Latitude of the point, required                                          |\n| ` + "`" + `lon` + "`" + `      | Longitude of the point, required                                         |\n| ` + "`" + `radius` + "`" + `   | Radius in meters, default ` + "`" + `500` + "`" + `, at most ` + "`" + `10000` + "`" + `                         |\n| ` + "`" + `at` + "`" + `       | Time of the snapshot, default the latest snapshot                        |\n| ` + "`" + `mode` + "`" + `     | ` + "`" + `after` + "`" + `, ` + "`" + `before` + "`" + ` or ` + "`" + `nearest` + "`" + `, see ` + "`" + `GET /api/v1/stations` + "`" + `               |\n| ` + "`" + `maxAge` + "`" + `   | Largest distance between ` + "`" + `at` + "`" + ` and the snapshot, ex: ` + "`" + `2h` + "`" + `                 |\n| ` + "`" + `minBikes` + "`" + ` | At least this number of bikes available                                  |\n| ` + "`" + `limit` + "`" + `    | At most this number of stations (1 to 500)                               |\n| ` + "`" + `offset` + "`" + `   | Skip this number of stations, used with ` + "`" + `limit` + "`" + `                          |\n\n` + "`" + `kioskStatus` + "`" + `, ` + "`" + `minDocks` + "`" + `, ` + "`" + `isVirtual` + "`" + `, ` + "`" + `zip` + "`" + `, ` + "`" + `kioskType` + "`" + ` and ` + "`" + `kioskIds` + "`" + ` filter the stations as for ` + "`" + `GET /api/v1/stations` + "`" + `. ` + "`" + `sort` + "`" + ` is rejected with ` + "`" + `400` + "`" + `, stations are always sorted by distance.\n\nEach station has the properties of the Indego API and its ` + "`" + `distance` + "`" + ` in meters to the point.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  at: '2024-11-08T01:00:00Z',\n  lat: 39.9526,\n  lon: -75.1652,\n  radius: 800,\n  stations: [\n    {\n      distance: 142.7,\n      id: 3005,\n      name: 'Welcome Park, NPS',\n      kioskId: 3005,\n      bikesAvailable: 6,\n      docksAvailable: 7,\n      /* other properties as per the Indego API */\n    }\n  ]\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                   |\n|------|-----------------------------------------------|\n| 200  | Stations found, may be empty                  |\n| 400  | Invalid lat, lon, radius or other parameter   |\n| 401  | Bad Authorization. Check token                |\n| 404  | No snapshot found                             |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Stations near a point",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "ex: 39.9526",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "ex: -75.1652",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "ex: 500",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2019-09-01T10:00:00Z",
+                        "name": "at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "after, before or nearest",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2h",
+                        "name": "maxAge",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: FullService",
+                        "name": "kioskStatus",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 3",
+                        "name": "minBikes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 2",
+                        "name": "minDocks",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "ex: false",
+                        "name": "isVirtual",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 19106",
+                        "name": "zip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 1",
+                        "name": "kioskType",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 3005,3006",
+                        "name": "kioskIds",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ex: 0",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/stations/stats": {
+            "get": {
+                "description": "## Hourly or daily stats of all stations\n\nRollup of the whole system for every hour or day starting between ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + `:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations/stats?granularity=day\u0026from=2024-11-01T00:00:00Z\u0026to=2024-11-30T00:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\n` + "`" + `granularity` + "`" + ` is ` + "`" + `hour` + "`" + ` (default) or ` + "`" + `day` + "`" + `, buckets are aligned to UTC. ` + "`" + `bikesAvg` + "`" + ` and ` + "`" + `docksAvg` + "`" + ` are the average number of bikes and free docks in the system, ` + "`" + `pctEmpty` + "`" + ` and ` + "`" + `pctFull` + "`" + ` the average over the stations of the percent of snapshots without bike and without free dock. ` + "`" + `stations` + "`" + ` is the number of stations in the bucket.\n\nAt most 5000 buckets are returned, use ` + "`" + `granularity=day` + "`" + ` for a longer range.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  granularity: 'day',\n  from: '2024-11-01T00:00:00Z',\n  to: '2024-11-30T00:00:00Z',\n  buckets: [\n    {\n      at: '2024-11-01T00:00:00Z',\n      stations: 262,\n      samples: 75456,\n      bikesAvg: 1403.52,\n      docksAvg: 2310.17,\n      pctEmpty: 6.31,\n      pctFull: 4.08\n    }\n  ]\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                          |\n|------|------------------------------------------------------|\n| 200  | Stats found                                          |\n| 400  | Invalid timestamp, granularity or too many buckets   |\n| 401  | Bad Authorization. Check token                       |\n| 404  | Data Not Found                                       |\n",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "API"
                 ],
-                "summary": "Store data from Indego",
+                "summary": "Hourly or daily stats of all stations",
                 "parameters": [
                     {
                         "type": "string",
@@ -32,21 +737,41 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "hour or day",
+                        "name": "granularity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-01T00:00:00Z",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-02T00:00:00Z",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {}
             }
         },
-        "/api/v1/stations": {
+        "/api/v1/stations/within": {
             "get": {
-                "description": "## Snapshot of all stations at a specified time\n\nData for all stations as of 11am Universal Coordinated Time on September 1st, 2019:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations?at=2019-09-01T10:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\nThis endpoint should respond as follows, with the actual time of the first snapshot of data on or after the requested time and the data:\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  at: '2019-09-01T10:00:00Z',\n  stations: { /* As per the Indego API */ },\n  weather: { /* As per the Open Weather Map API response for Philadelphia */ }\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Fetch and store to database is success |\n| 401  | Bad Authorization. Check token         |\n| 404  | Data Not Found                         |\n",
+                "description": "## Stations inside a bounding box\n\nStations of the snapshot inside ` + "`" + `bbox` + "`" + `, as GeoJSON FeatureCollection in the same shape as the Indego API:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations/within?bbox=-75.18,39.94,-75.14,39.96\u0026at=2019-09-01T10:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\n` + "`" + `bbox` + "`" + ` is ` + "`" + `minLon,minLat,maxLon,maxLat` + "`" + ` in WGS84. Without ` + "`" + `at` + "`" + ` the latest snapshot is used, ` + "`" + `mode` + "`" + ` and ` + "`" + `maxAge` + "`" + ` select the snapshot as on ` + "`" + `GET /api/v1/stations` + "`" + `. The filter, ` + "`" + `sort` + "`" + `, ` + "`" + `limit` + "`" + ` and ` + "`" + `offset` + "`" + ` parameters of ` + "`" + `GET /api/v1/stations` + "`" + ` can be used too.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  type: 'FeatureCollection',\n  features: [ /* Stations inside the bounding box as per the Indego API */ ],\n  last_updated: '2019-09-01T10:00:00Z'\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Stations found, may be empty           |\n| 400  | Invalid bbox or other parameter        |\n| 401  | Bad Authorization. Check token         |\n| 404  | No snapshot found                      |\n",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "API"
                 ],
-                "summary": "Snapshot of one station at a specific time",
+                "summary": "Stations inside a bounding box",
                 "parameters": [
                     {
                         "type": "string",
@@ -55,12 +780,80 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ex: -75.18,39.94,-75.14,39.96",
+                        "name": "bbox",
+                        "in": "query",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "ex: 2019-09-01T10:00:00Z",
                         "name": "at",
-                        "in": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "after, before or nearest",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2h",
+                        "name": "maxAge",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            },
+            "post": {
+                "description": "## Stations inside a GeoJSON Polygon or MultiPolygon\n\nStations of the snapshot inside the area of the body, as GeoJSON FeatureCollection in the same shape as the Indego API:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nPOST http://localhost:3000/api/v1/stations/within?at=2019-09-01T10:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\nThe body is a GeoJSON ` + "`" + `Polygon` + "`" + ` or ` + "`" + `MultiPolygon` + "`" + ` geometry, or a ` + "`" + `Feature` + "`" + ` with such geometry, in WGS84. Each ring must be closed. The body is at most 1 MB.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  type: 'Polygon',\n  coordinates: [[[-75.18, 39.94], [-75.14, 39.94], [-75.14, 39.96], [-75.18, 39.96], [-75.18, 39.94]]]\n}\n` + "`" + `` + "`" + `` + "`" + `\n\nWithout ` + "`" + `at` + "`" + ` the latest snapshot is used, ` + "`" + `mode` + "`" + ` and ` + "`" + `maxAge` + "`" + ` select the snapshot as on ` + "`" + `GET /api/v1/stations` + "`" + `. The filter, ` + "`" + `sort` + "`" + `, ` + "`" + `limit` + "`" + ` and ` + "`" + `offset` + "`" + ` parameters of ` + "`" + `GET /api/v1/stations` + "`" + ` can be used too.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  type: 'FeatureCollection',\n  features: [ /* Stations inside the area as per the Indego API */ ],\n  last_updated: '2019-09-01T10:00:00Z'\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Stations found, may be empty           |\n| 400  | Invalid GeoJSON or other parameter     |\n| 401  | Bad Authorization. Check token         |\n| 404  | No snapshot found                      |\n",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Stations inside a GeoJSON Polygon or MultiPolygon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2019-09-01T10:00:00Z",
+                        "name": "at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "after, before or nearest",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2h",
+                        "name": "maxAge",
+                        "in": "query"
+                    },
+                    {
+                        "description": "GeoJSON Polygon or MultiPolygon",
+                        "name": "polygon",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
                     }
                 ],
                 "responses": {}
@@ -68,7 +861,7 @@ const docTemplate = `{
         },
         "/api/v1/stations/{kioskId}": {
             "get": {
-                "description": "## Snapshot of one station at a specific time\n\nData for a specific station (by its ` + "`" + `kioskId` + "`" + `) at a specific time:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations/{kioskId}?at=2019-09-01T10:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\nThe response should be the first available on or after the given time, and should look like:\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  at: '2019-09-01T10:00:00',\n  station: { /* Data just for this one station as per the Indego API */ },\n  weather: { /* As per the Open Weather Map API response for Philadelphia */ }\n}\n` + "`" + `` + "`" + `` + "`" + `\n\nInclude an ` + "`" + `at` + "`" + ` property in the same format indicating the actual time of the snapshot.\n\nIf no suitable data is available a 404 status code should be given.\n\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Fetch and store to database is success |\n| 401  | Bad Authorization. Check token         |\n| 404  | Data Not Found                         |\n",
+                "description": "## Snapshot of one station at a specific time\n\nData for a specific station (by its ` + "`" + `kioskId` + "`" + `) at a specific time:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations/{kioskId}?at=2019-09-01T10:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\nThe response should be the first available on or after the given time, and should look like:\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  at: '2019-09-01T10:00:00',\n  station: { /* Data just for this one station as per the Indego API */ },\n  weather: { /* As per the Open Weather Map API response for Philadelphia */ }\n}\n` + "`" + `` + "`" + `` + "`" + `\n\nInclude an ` + "`" + `at` + "`" + ` property in the same format indicating the actual time of the snapshot.\n\nIf no suitable data is available a 404 status code should be given.\n\n\nUse ` + "`" + `mode` + "`" + ` to select the snapshot relative to ` + "`" + `at` + "`" + `:\n\n| mode      | Snapshot                                          |\n|-----------|---------------------------------------------------|\n| ` + "`" + `after` + "`" + `   | First snapshot on or after ` + "`" + `at` + "`" + ` (default)         |\n| ` + "`" + `before` + "`" + `  | Last snapshot on or before ` + "`" + `at` + "`" + `                   |\n| ` + "`" + `nearest` + "`" + ` | Snapshot closest to ` + "`" + `at` + "`" + `, the earlier one on ties |\n\n` + "`" + `maxAge` + "`" + ` (ex: ` + "`" + `30m` + "`" + `, ` + "`" + `2h` + "`" + `) is the largest distance allowed between ` + "`" + `at` + "`" + ` and the snapshot. When no snapshot is close enough the response is 404, so a request for 10:00 never returns data from three days later.\n\nThe weather linked to the snapshot is returned when the snapshot was fetched together with the weather, or when the scheduler fetched the weather within one hour after the snapshot. Otherwise the weather is selected with the same ` + "`" + `mode` + "`" + ` and ` + "`" + `maxAge` + "`" + ` relative to the time of the snapshot. Without ` + "`" + `mode` + "`" + ` the latest observation on or before the snapshot is preferred.\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Fetch and store to database is success |\n| 400  | Invalid Request Parameter              |\n| 401  | Bad Authorization. Check token         |\n| 404  | Data Not Found                         |\n",
                 "produces": [
                     "application/json"
                 ],
@@ -97,6 +890,181 @@ const docTemplate = `{
                         "name": "kioskId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "after, before or nearest",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2h",
+                        "name": "maxAge",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/stations/{kioskId}/forecast": {
+            "get": {
+                "description": "## Forecast of bikes and docks of one station\n\nPredict ` + "`" + `bikesAvailable` + "`" + ` and ` + "`" + `docksAvailable` + "`" + ` of one station (by its ` + "`" + `kioskId` + "`" + `) at a future time ` + "`" + `at` + "`" + `:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations/{kioskId}/forecast?at=2026-11-08T17:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\nThe baseline is the mean of the station snapshots taken on the same weekday and hour as ` + "`" + `at` + "`" + ` in Philadelphia local time (` + "`" + `America/New_York` + "`" + `, returned in ` + "`" + `timeZone` + "`" + `) over the last 84 days, ` + "`" + `samples` + "`" + ` is the number of those snapshots. When ` + "`" + `at` + "`" + ` is within 24 hours, the latest weather stored in the last 24 hours is returned in ` + "`" + `weather` + "`" + `. If at least 5 of the snapshots were taken in a similar weather (rain or no rain like now, temperature within 5 °C), the prediction is their mean instead and ` + "`" + `weather.adjusted` + "`" + ` is ` + "`" + `true` + "`" + `.\n\n` + "`" + `low` + "`" + ` and ` + "`" + `high` + "`" + ` are the 95% confidence band, from the spread of the snapshots on the same weekday and hour, bounded by 0 and the docks of the station.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  kioskId: 3005,\n  at: '2026-11-08T17:00:00Z',\n  weekday: 'Sunday',\n  hour: 12,\n  timeZone: 'America/New_York',\n  samples: 143,\n  lookbackDays: 84,\n  bikesAvailable: { value: 6.42, low: 1.1, high: 11.74 },\n  docksAvailable: { value: 12.3, low: 7.02, high: 17.58 },\n  weather: {\n    at: '2026-11-08T15:00:00Z',\n    temp: 9.5,\n    rainOneHour: 0,\n    adjusted: true,\n    samples: 61\n  }\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                      |\n|------|--------------------------------------------------|\n| 200  | Forecast found                                   |\n| 400  | Invalid kioskId, timestamp or at not in future   |\n| 401  | Bad Authorization. Check token                   |\n| 404  | Data Not Found                                   |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Forecast of bikes and docks of one station",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 3005",
+                        "name": "kioskId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2026-11-08T17:00:00Z",
+                        "name": "at",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/stations/{kioskId}/history": {
+            "get": {
+                "description": "## Availability history of one station\n\nTime series of one station (by its ` + "`" + `kioskId` + "`" + `) for every stored snapshot between ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + `:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations/{kioskId}/history?from=2024-11-01T00:00:00Z\u0026to=2024-11-02T00:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\nAdd ` + "`" + `interval` + "`" + ` to downsample the series, ex: ` + "`" + `interval=1h` + "`" + ` for a day or ` + "`" + `interval=24h` + "`" + ` for a month. ` + "`" + `interval` + "`" + ` is a Go duration (` + "`" + `15m` + "`" + `, ` + "`" + `1h` + "`" + `, ` + "`" + `24h` + "`" + `), the minimum is ` + "`" + `1m` + "`" + `. Snapshots are grouped in buckets aligned to UTC, counts are the average over the bucket, ` + "`" + `kioskStatus` + "`" + ` is the status on the last snapshot of the bucket and ` + "`" + `samples` + "`" + ` the number of snapshots in it.\n\nAt most 5000 points are returned, use a larger ` + "`" + `interval` + "`" + ` for a longer range.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  kioskId: 3005,\n  from: '2024-11-01T00:00:00Z',\n  to: '2024-11-02T00:00:00Z',\n  interval: '1h0m0s',\n  points: [\n    {\n      at: '2024-11-01T00:00:00Z',\n      bikesAvailable: 4.5,\n      docksAvailable: 8.5,\n      classicBikesAvailable: 3,\n      electricBikesAvailable: 1.5,\n      smartBikesAvailable: 0,\n      kioskStatus: 'FullService',\n      samples: 2\n    }\n  ]\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                             |\n|------|---------------------------------------------------------|\n| 200  | History found                                           |\n| 400  | Invalid kioskId, timestamp, interval or too many points |\n| 401  | Bad Authorization. Check token                          |\n| 404  | Data Not Found                                          |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Availability history of one station",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 3005",
+                        "name": "kioskId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-01T00:00:00Z",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-02T00:00:00Z",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 1h",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/stations/{kioskId}/latest": {
+            "get": {
+                "description": "## Latest snapshot of one station\n\nThe station (by its ` + "`" + `kioskId` + "`" + `) in the most recent snapshot, without ` + "`" + `at` + "`" + `:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations/{kioskId}/latest\n` + "`" + `` + "`" + `` + "`" + `\n\nThe snapshot is served from memory, ` + "`" + `age` + "`" + ` and ` + "`" + `stale` + "`" + ` are described on ` + "`" + `GET /api/v1/stations/latest` + "`" + `.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  at: '2024-11-08T01:00:00Z',\n  age: 312,\n  stale: false,\n  stations: { /* Data just for this one station as per the Indego API */ },\n  weather: { /* As per the Open Weather Map API response for Philadelphia */ }\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                            |\n|------|----------------------------------------|\n| 200  | Latest snapshot found                  |\n| 400  | Invalid kioskId format                 |\n| 401  | Bad Authorization. Check token         |\n| 404  | Data Not Found                         |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Latest snapshot of one station",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 3005",
+                        "name": "kioskId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/stations/{kioskId}/stats": {
+            "get": {
+                "description": "## Hourly or daily stats of one station\n\nRollup of one station (by its ` + "`" + `kioskId` + "`" + `) for every hour or day starting between ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + `:\n\n` + "`" + `` + "`" + `` + "`" + `bash\nGET http://localhost:3000/api/v1/stations/{kioskId}/stats?granularity=hour\u0026from=2024-11-01T00:00:00Z\u0026to=2024-11-02T00:00:00Z\n` + "`" + `` + "`" + `` + "`" + `\n\n` + "`" + `granularity` + "`" + ` is ` + "`" + `hour` + "`" + ` (default) or ` + "`" + `day` + "`" + `. Hours are aligned to UTC, days start at midnight in Philadelphia (` + "`" + `America/New_York` + "`" + `), so a day bucket is at ` + "`" + `05:00Z` + "`" + ` or ` + "`" + `04:00Z` + "`" + ` during daylight saving time. The rollups are kept in tables ` + "`" + `station_hourly` + "`" + ` and ` + "`" + `station_daily` + "`" + `, refreshed after each stored snapshot. Each bucket has the min, max and average of ` + "`" + `bikesAvailable` + "`" + ` and ` + "`" + `docksAvailable` + "`" + `, ` + "`" + `pctEmpty` + "`" + ` and ` + "`" + `pctFull` + "`" + ` the percent of snapshots without bike and without free dock, and ` + "`" + `samples` + "`" + ` the number of snapshots.\n\nAt most 5000 buckets are returned, use ` + "`" + `granularity=day` + "`" + ` for a longer range.\n\n` + "`" + `` + "`" + `` + "`" + `javascript\n{\n  kioskId: 3005,\n  granularity: 'hour',\n  from: '2024-11-01T00:00:00Z',\n  to: '2024-11-02T00:00:00Z',\n  buckets: [\n    {\n      at: '2024-11-01T00:00:00Z',\n      samples: 12,\n      bikesMin: 2,\n      bikesMax: 6,\n      bikesAvg: 4.25,\n      docksMin: 7,\n      docksMax: 11,\n      docksAvg: 8.75,\n      pctEmpty: 0,\n      pctFull: 0\n    }\n  ]\n}\n` + "`" + `` + "`" + `` + "`" + `\n\n### Token \nAdd HTTP header with Authorization \n` + "`" + `` + "`" + `` + "`" + `go\n\n  // example:\n\theaders := map[string]string{\n\t\t\"Authorization\": \"Bearer secret_token_static\",\n\t},\n` + "`" + `` + "`" + `` + "`" + `\n\n### Response Code\n| HTTP | Description                                                   |\n|------|---------------------------------------------------------------|\n| 200  | Stats found                                                   |\n| 400  | Invalid kioskId, timestamp, granularity or too many buckets   |\n| 401  | Bad Authorization. Check token                                |\n| 404  | Data Not Found                                                |\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API"
+                ],
+                "summary": "Hourly or daily stats of one station",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer secret_token_static",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 3005",
+                        "name": "kioskId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "hour or day",
+                        "name": "granularity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-01T00:00:00Z",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ex: 2024-11-02T00:00:00Z",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker is the circuit breaker of one upstream host. It opens after
+// consecutive failures and let requests through again after the cooldown,
+// one more failure then open it again.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+type breakerMap struct {
+	mu    sync.Mutex
+	hosts map[string]*breaker
+}
+
+var breakers = &breakerMap{hosts: make(map[string]*breaker)}
+
+func (m *breakerMap) get(host string) *breaker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cb, ok := m.hosts[host]
+	if !ok {
+		cb = &breaker{}
+		m.hosts[host] = cb
+	}
+	return cb
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return time.Now().After(b.openUntil)
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.openUntil = time.Time{}
+}
+
+func (b *breaker) failure(policy RetryPolicy) {
+	if policy.BreakerThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures >= policy.BreakerThreshold {
+		b.openUntil = time.Now().Add(policy.BreakerCooldown)
+	}
+}
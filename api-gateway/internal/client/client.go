@@ -6,26 +6,115 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
+// RetryPolicy set how SendHTTPRequest retry a failed request and when the
+// circuit breaker of the host is opened. Each caller owns its policy.
+type RetryPolicy struct {
+	// MaxAttempts include the first request, value below 1 means 1
+	MaxAttempts int
+	// delay before the n-th retry is BaseDelay * 2^n with jitter, up to MaxDelay.
+	// Retry-After longer than MaxDelay stop the retry
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// breaker open after BreakerThreshold consecutive failures of the host
+	// and fail fast for BreakerCooldown. Zero threshold disable the breaker
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// one client for all callers so connections to upstream are reused
+var httpClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          20,
+		MaxIdleConnsPerHost:   5,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	},
+}
+
+// attemptError is the result of one failed attempt
+type attemptError struct {
+	httpCode   int
+	err        error
+	retryable  bool
+	retryAfter time.Duration
+}
+
 func SendHTTPRequest(
 	httpMethod string,
 	timeout time.Duration,
 	headers map[string]string,
 	url string,
+	policy RetryPolicy,
 ) ([]byte, int, error) {
+	host := hostOf(url)
+	cb := breakers.get(host)
+
+	attempts := max(policy.MaxAttempts, 1)
+	for attempt := 0; ; attempt++ {
+		if !cb.allow() {
+			log.Warn().Str("host", host).Msg("SendHTTPRequest -> circuit breaker open")
+			return nil, http.StatusServiceUnavailable, fmt.Errorf("Circuit breaker open for %s", host)
+		}
+
+		body, failed := sendOnce(httpMethod, timeout, headers, url)
+		if failed == nil {
+			cb.success()
+			return body, http.StatusOK, nil
+		}
+		if failed.retryable {
+			cb.failure(policy)
+		}
+
+		if !failed.retryable || attempt+1 >= attempts {
+			return nil, failed.httpCode, failed.err
+		}
+
+		delay := backoff(policy, attempt)
+		if failed.retryAfter > 0 {
+			if failed.retryAfter > policy.MaxDelay {
+				return nil, failed.httpCode, failed.err
+			}
+			delay = failed.retryAfter
+		}
+
+		log.Warn().Err(failed.err).
+			Str("host", host).
+			Int("attempt", attempt+1).
+			Dur("delay", delay).
+			Msg("SendHTTPRequest -> retry")
+		time.Sleep(delay)
+	}
+}
+
+func sendOnce(
+	httpMethod string,
+	timeout time.Duration,
+	headers map[string]string,
+	url string,
+) ([]byte, *attemptError) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	client := &http.Client{}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, httpMethod, url, nil)
 	if err != nil {
 		log.Error().Err(err).Msg("SendHTTPRequest -> NewRequestWithContext")
-		return nil, http.StatusInternalServerError, errors.New("Error creating request")
+		return nil, &attemptError{httpCode: http.StatusInternalServerError, err: errors.New("Error creating request")}
 	}
 
 	// Add headers to the request
@@ -33,28 +122,75 @@ func SendHTTPRequest(
 		req.Header.Add(key, value)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		log.Error().Err(err).Msg("SendHTTPRequest -> client.Do")
-		if err == context.DeadlineExceeded {
-			return nil, http.StatusRequestTimeout, errors.New("Request timed out")
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, &attemptError{httpCode: http.StatusRequestTimeout, err: errors.New("Request timed out"), retryable: true}
 		} else {
-			return nil, http.StatusInternalServerError, errors.New("Error sending request")
+			return nil, &attemptError{httpCode: http.StatusInternalServerError, err: errors.New("Error sending request"), retryable: true}
 		}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, resp.StatusCode, fmt.Errorf("Unexpected status code: %d", resp.StatusCode)
+		// drain the body so the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, &attemptError{
+			httpCode:   resp.StatusCode,
+			err:        fmt.Errorf("Unexpected status code: %d", resp.StatusCode),
+			retryable:  resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Error().Err(err).Msg("SendHTTPRequest -> io.ReadAll")
-		return nil, http.StatusInternalServerError, errors.New("Error reading response body")
+		return nil, &attemptError{httpCode: http.StatusInternalServerError, err: errors.New("Error reading response body"), retryable: true}
+	}
+
+	return body, nil
+}
+
+// backoff returns exponential delay with equal jitter
+func backoff(policy RetryPolicy, attempt int) time.Duration {
+	delay := policy.BaseDelay << attempt
+	if delay <= 0 || (policy.MaxDelay > 0 && delay > policy.MaxDelay) {
+		delay = policy.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// parseRetryAfter accept delay in seconds or HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+
+	var seconds int
+	if _, err := fmt.Sscanf(value, "%d", &seconds); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
 	}
 
-	return body, http.StatusOK, nil
+	return 0
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Host
 }
 
 func GetJSON(body []byte, v interface{}) error {
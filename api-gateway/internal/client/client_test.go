@@ -0,0 +1,140 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    10 * time.Millisecond,
+}
+
+func newServer(handler func(w http.ResponseWriter, n int32)) (*httptest.Server, *int32) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, atomic.AddInt32(&calls, 1))
+	}))
+	return ts, &calls
+}
+
+func TestSendHTTPRequestRetry(t *testing.T) {
+	scenarios := []struct {
+		name          string
+		handler       func(w http.ResponseWriter, n int32)
+		expectedCode  int
+		expectedCalls int32
+	}{
+		{
+			name: "Success after 5xx",
+			handler: func(w http.ResponseWriter, n int32) {
+				if n < 3 {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				w.Write([]byte(`{}`))
+			},
+			expectedCode:  http.StatusOK,
+			expectedCalls: 3,
+		},
+		{
+			name: "Fail - 5xx until max attempts",
+			handler: func(w http.ResponseWriter, n int32) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			expectedCode:  http.StatusServiceUnavailable,
+			expectedCalls: 3,
+		},
+		{
+			name: "Fail - 4xx is not retried",
+			handler: func(w http.ResponseWriter, n int32) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			expectedCode:  http.StatusUnauthorized,
+			expectedCalls: 1,
+		},
+		{
+			name: "Success after Retry-After",
+			handler: func(w http.ResponseWriter, n int32) {
+				if n == 1 {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Write([]byte(`{}`))
+			},
+			expectedCode:  http.StatusOK,
+			expectedCalls: 2,
+		},
+		{
+			name: "Fail - Retry-After longer than max delay",
+			handler: func(w http.ResponseWriter, n int32) {
+				w.Header().Set("Retry-After", "120")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			expectedCode:  http.StatusTooManyRequests,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			server, calls := newServer(ts.handler)
+			defer server.Close()
+
+			_, httpCode, _ := SendHTTPRequest(http.MethodGet, time.Second, nil, server.URL, testPolicy)
+			if httpCode != ts.expectedCode {
+				t.Errorf("Expected status %d but got response %d", ts.expectedCode, httpCode)
+			}
+
+			if n := atomic.LoadInt32(calls); n != ts.expectedCalls {
+				t.Errorf("Expected %d calls but got %d", ts.expectedCalls, n)
+			}
+		})
+	}
+}
+
+func TestSendHTTPRequestBreaker(t *testing.T) {
+	server, calls := newServer(func(w http.ResponseWriter, n int32) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer server.Close()
+
+	policy := RetryPolicy{
+		MaxAttempts:      1,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Hour,
+	}
+
+	for i := 0; i < 2; i++ {
+		SendHTTPRequest(http.MethodGet, time.Second, nil, server.URL, policy)
+	}
+
+	_, httpCode, err := SendHTTPRequest(http.MethodGet, time.Second, nil, server.URL, policy)
+	if err == nil || httpCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d but got response %d", http.StatusServiceUnavailable, httpCode)
+	}
+
+	if n := atomic.LoadInt32(calls); n != 2 {
+		t.Errorf("Expected breaker to fail fast after 2 calls but got %d", n)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter("3"); d != 3*time.Second {
+		t.Errorf("Expected 3s but got %v", d)
+	}
+
+	at := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if d := parseRetryAfter(at); d <= 0 || d > time.Minute {
+		t.Errorf("Expected delay up to 1m but got %v", d)
+	}
+
+	if d := parseRetryAfter("soon"); d != 0 {
+		t.Errorf("Expected 0 but got %v", d)
+	}
+}
@@ -19,6 +19,17 @@ type EnvParams struct {
 	OpenWeather struct {
 		APIKey string `yaml:"apikey"`
 	} `yaml:"openweather"`
+	Scheduler struct {
+		IndegoInterval  int `yaml:"indegoInterval"`
+		WeatherInterval int `yaml:"weatherInterval"`
+		Jitter          int `yaml:"jitter"`
+	} `yaml:"scheduler"`
+	Anomaly struct {
+		StuckSnapshots int `yaml:"stuckSnapshots"`
+	} `yaml:"anomaly"`
+	Battery struct {
+		LowThreshold int `yaml:"lowThreshold"`
+	} `yaml:"battery"`
 }
 
 func LoadConfig() (*EnvParams, error) {
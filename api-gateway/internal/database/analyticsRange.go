@@ -0,0 +1,22 @@
+package database
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxAnalyticsRange limit the range of snapshots scanned by one analytics
+// request
+const MaxAnalyticsRange = 31 * 24 * time.Hour
+
+// ValidateAnalyticsRange returns an error when from is after to or the
+// range is longer than MaxAnalyticsRange
+func ValidateAnalyticsRange(from time.Time, to time.Time) error {
+	if from.After(to) {
+		return errors.New("from must be before to")
+	}
+	if to.Sub(from) > MaxAnalyticsRange {
+		return errors.New("Range too long, at most 31 days")
+	}
+	return nil
+}
@@ -0,0 +1,479 @@
+package database
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arthben/BackendGolang/api-gateway/internal/config"
+	"github.com/google/uuid"
+)
+
+// the tests below run the analytics queries on a running database, on
+// snapshots stored in 2001 with kiosks no real station use
+const (
+	testKioskA = 990001
+	testKioskB = 990002
+	// testCityID is the openweather city of the test observations
+	testCityID = -1
+)
+
+var (
+	poolOnce sync.Once
+	pool     DBService
+	poolErr  error
+)
+
+// testPool connect once to the database of config/config.yaml
+func testPool(t *testing.T) *dbase {
+	poolOnce.Do(func() {
+		wd, err := os.Getwd()
+		if err != nil {
+			poolErr = err
+			return
+		}
+
+		os.Chdir("../..")
+		cfg, err := config.LoadConfig()
+		os.Chdir(wd)
+		if err != nil {
+			poolErr = err
+			return
+		}
+
+		pool, poolErr = NewPool(cfg)
+	})
+
+	if poolErr != nil {
+		t.Fatalf("Expected no error while connect to database, but error occur %s\n", poolErr)
+	}
+	return pool.(*dbase)
+}
+
+// testStation is one station of a test snapshot, Batteries are the levels
+// of its electric bikes
+type testStation struct {
+	KioskID   int
+	Zip       string
+	KioskType int
+	Bikes     int
+	Docks     int
+	Batteries []int
+}
+
+// insertSnapshot insert a snapshot taken at with stations, linked to the
+// weather weatherID when not empty. The rows are deleted when t ends
+func insertSnapshot(t *testing.T, d *dbase, at time.Time, weatherID string, stations ...testStation) string {
+	fetchID := uuid.New().String()
+	t.Cleanup(func() {
+		deleteRows(t, d, fetchID, "rideindego_properties_bikes", "rideindego_properties", "rideindego_master")
+	})
+
+	var linked *string
+	if len(weatherID) > 0 {
+		linked = &weatherID
+	}
+
+	sql := `INSERT INTO rideindego_master (fetch_id, type_collection, last_update, weather_fetch_id)
+			VALUES ($1, 'FeatureCollection', $2, $3)`
+	if _, err := d.db.Exec(sql, fetchID, at, linked); err != nil {
+		t.Fatalf("Expected no error while store snapshot, but error occur %s\n", err)
+	}
+
+	for i, station := range stations {
+		sql = `INSERT INTO rideindego_properties (fetch_id, feat_id, id, name, kiosk_id,
+				kiosk_type, address_zip_code, bikes_available, docks_available, total_docks)
+				VALUES ($1, $2, $2, $3, $4, $5, $6, $7, $8, $9)`
+		_, err := d.db.Exec(sql, fetchID, i, "Test Station", station.KioskID, station.KioskType,
+			station.Zip, station.Bikes, station.Docks, station.Bikes+station.Docks)
+		if err != nil {
+			t.Fatalf("Expected no error while store properties, but error occur %s\n", err)
+		}
+
+		for dock, battery := range station.Batteries {
+			sql = `INSERT INTO rideindego_properties_bikes (fetch_id, feat_id, id, battery,
+					dock_number, is_electric, is_available)
+					VALUES ($1, $2, $2, $3, $4, true, true)`
+			if _, err := d.db.Exec(sql, fetchID, i, battery, dock+1); err != nil {
+				t.Fatalf("Expected no error while store bikes, but error occur %s\n", err)
+			}
+		}
+	}
+
+	return fetchID
+}
+
+// insertWeather insert an observation at dt with its condition. The rows are
+// deleted when t ends
+func insertWeather(t *testing.T, d *dbase, dt time.Time, temp float64, rain float64, wind float64, condition string) string {
+	fetchID := uuid.New().String()
+	t.Cleanup(func() { deleteRows(t, d, fetchID, "openweather_weather", "openweather_master") })
+
+	sql := `INSERT INTO openweather_master (fetch_id, dt, id, main_temp, rain_one_hour, wind_speed)
+			VALUES ($1, $2, $3, $4, $5, $6)`
+	if _, err := d.db.Exec(sql, fetchID, dt.Unix(), testCityID, temp, rain, wind); err != nil {
+		t.Fatalf("Expected no error while store weather, but error occur %s\n", err)
+	}
+
+	sql = `INSERT INTO openweather_weather (fetch_id, main) VALUES ($1, $2)`
+	if _, err := d.db.Exec(sql, fetchID, condition); err != nil {
+		t.Fatalf("Expected no error while store weather detail, but error occur %s\n", err)
+	}
+
+	return fetchID
+}
+
+func deleteRows(t *testing.T, d *dbase, fetchID string, tables ...string) {
+	for _, table := range tables {
+		if _, err := d.db.Exec(`DELETE FROM `+table+` WHERE fetch_id = $1`, fetchID); err != nil {
+			t.Errorf("Expected no error while delete %s, but error occur %s\n", table, err)
+		}
+	}
+}
+
+func TestRefreshStationRollups(t *testing.T) {
+	d := testPool(t)
+	// 22:00 on March 1 in Philadelphia, the day before the other snapshots
+	from := time.Date(2001, 3, 2, 3, 0, 0, 0, time.UTC)
+	t.Cleanup(func() {
+		for _, table := range []string{"station_hourly", "station_daily"} {
+			if _, err := d.db.Exec(`DELETE FROM `+table+` WHERE kiosk_id = $1`, testKioskA); err != nil {
+				t.Errorf("Expected no error while delete %s, but error occur %s\n", table, err)
+			}
+		}
+	})
+
+	// the 10:00 hour has two snapshots, one of them empty, and the 11:00 hour
+	// one full snapshot
+	for _, snapshot := range []struct {
+		at    time.Duration
+		bikes int
+	}{
+		{0, 2},
+		{7 * time.Hour, 0},
+		{7*time.Hour + 30*time.Minute, 4},
+		{8 * time.Hour, 10},
+	} {
+		insertSnapshot(t, d, from.Add(snapshot.at), "", testStation{KioskID: testKioskA, Bikes: snapshot.bikes, Docks: 10 - snapshot.bikes})
+	}
+
+	if err := d.RefreshStationRollups(context.Background(), from, from.Add(8*time.Hour)); err != nil {
+		t.Fatalf("Expected no error, but error occur %s\n", err)
+	}
+
+	day := time.Date(2001, 3, 2, 5, 0, 0, 0, time.UTC)
+	rows, err := d.SearchStationRollups(context.Background(), RollupDay, testKioskA, day.Add(-24*time.Hour), day, 10)
+	if err != nil {
+		t.Fatalf("Expected no error, but error occur %s\n", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 days but got %d", len(rows))
+	}
+
+	// the days start at midnight in Philadelphia, the first snapshot is
+	// alone in March 1
+	if !rows[0].Bucket.Equal(day.Add(-24*time.Hour)) || rows[0].Samples != 1 {
+		t.Errorf("Expected 1 sample on 2001-03-01T05:00:00Z but got %d on %v", rows[0].Samples, rows[0].Bucket)
+	}
+
+	// pct_empty and pct_full are weighted by samples, 50% empty on 2 samples
+	// and 100% full on 1 sample is a third of each, not the 25% and 50% of
+	// the average of the hours
+	expected := StationRollup{
+		KioskID:  testKioskA,
+		Bucket:   day,
+		Samples:  3,
+		BikesMin: 0,
+		BikesMax: 10,
+		BikesAvg: 4.67,
+		DocksMin: 0,
+		DocksMax: 10,
+		DocksAvg: 5.33,
+		PctEmpty: 33.33,
+		PctFull:  33.33,
+	}
+	row := *rows[1]
+	if !row.Bucket.Equal(expected.Bucket) {
+		t.Errorf("Expected the day at %v but got %v", expected.Bucket, row.Bucket)
+	}
+	row.Bucket = expected.Bucket
+	if row != expected {
+		t.Errorf("Expected %+v but got %+v", expected, row)
+	}
+}
+
+func TestSearchUtilization(t *testing.T) {
+	d := testPool(t)
+	from := time.Date(2001, 3, 5, 8, 0, 0, 0, time.UTC)
+
+	// kiosk A is empty every other snapshot, kiosk B is always full
+	for i, bikes := range []int{0, 2, 0, 2} {
+		insertSnapshot(t, d, from.Add(time.Duration(i)*time.Hour), "",
+			testStation{KioskID: testKioskA, Zip: "19103", KioskType: 1, Bikes: bikes, Docks: 10 - bikes},
+			testStation{KioskID: testKioskB, Zip: "19104", KioskType: 2, Bikes: 5, Docks: 0},
+		)
+	}
+
+	scenarios := []struct {
+		name     string
+		query    UtilizationQuery
+		expected []StationUtilization
+	}{
+		{
+			name:  "Rank by empty",
+			query: UtilizationQuery{RankBy: RankEmpty, Top: 10},
+			expected: []StationUtilization{
+				{Rank: 1, KioskID: testKioskA, Samples: 4, PctEmpty: 50, Turnover: 2, Total: 2},
+				{Rank: 2, KioskID: testKioskB, Samples: 4, PctFull: 100, Total: 2},
+			},
+		},
+		{
+			name:  "Rank by full, top 1",
+			query: UtilizationQuery{RankBy: RankFull, Top: 1},
+			expected: []StationUtilization{
+				{Rank: 1, KioskID: testKioskB, Samples: 4, PctFull: 100, Total: 2},
+			},
+		},
+		{
+			name:  "Filter by zip",
+			query: UtilizationQuery{RankBy: RankTurnover, Zip: "19103", Top: 10},
+			expected: []StationUtilization{
+				{Rank: 1, KioskID: testKioskA, Samples: 4, PctEmpty: 50, Turnover: 2, Total: 1},
+			},
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			ts.query.From, ts.query.To = from, from.Add(3*time.Hour)
+			rows, err := d.SearchUtilization(context.Background(), ts.query)
+			if err != nil {
+				t.Fatalf("Expected no error, but error occur %s\n", err)
+			}
+
+			if len(rows) != len(ts.expected) {
+				t.Fatalf("Expected %d stations but got %d", len(ts.expected), len(rows))
+			}
+			for i, row := range rows {
+				expected := ts.expected[i]
+				if row.Rank != expected.Rank || row.KioskID != expected.KioskID || row.Samples != expected.Samples ||
+					row.PctEmpty != expected.PctEmpty || row.PctFull != expected.PctFull ||
+					row.Turnover != expected.Turnover || row.Total != expected.Total {
+					t.Errorf("Expected %+v but got %+v", expected, *row)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchWeatherImpact(t *testing.T) {
+	d := testPool(t)
+	from := time.Date(2001, 3, 6, 8, 0, 0, 0, time.UTC)
+
+	// the first snapshot is linked, the second matched by the nearest
+	// observation and the third has no weather within one hour
+	clear := insertWeather(t, d, from, 290.15, 0, 2, "Clear")
+	rain := insertWeather(t, d, from.Add(70*time.Minute), 280.15, 3, 9, "Rain")
+
+	// an observation without wind is not a gale
+	if _, err := d.db.Exec(`UPDATE openweather_master SET wind_speed = NULL WHERE fetch_id = $1`, rain); err != nil {
+		t.Fatalf("Expected no error while update weather, but error occur %s\n", err)
+	}
+
+	insertSnapshot(t, d, from, clear, testStation{KioskID: testKioskA, Bikes: 4, Docks: 6})
+	insertSnapshot(t, d, from.Add(time.Hour), "", testStation{KioskID: testKioskA, Bikes: 1, Docks: 9})
+	insertSnapshot(t, d, from.Add(4*time.Hour), "", testStation{KioskID: testKioskA, Bikes: 3, Docks: 7})
+
+	kioskID := testKioskA
+	rows, err := d.SearchWeatherImpact(context.Background(), WeatherImpactQuery{
+		KioskID: &kioskID,
+		From:    from,
+		To:      from.Add(4 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but error occur %s\n", err)
+	}
+
+	// one band of each dimension per weather, the turnover of the first
+	// snapshot has no previous one
+	expected := []WeatherImpact{
+		{Dimension: WeatherCondition, Band: "Clear", Samples: 1, BikesAvg: 4},
+		{Dimension: WeatherCondition, Band: "Rain", Samples: 1, BikesAvg: 1, Turnover: 3},
+		{Dimension: WeatherRain, Band: "none", Samples: 1, BikesAvg: 4},
+		{Dimension: WeatherRain, Band: "moderate", BandOrder: 2, Samples: 1, BikesAvg: 1, Turnover: 3},
+		{Dimension: WeatherTemperature, Band: "5..10", BandOrder: 5, Samples: 1, BikesAvg: 1, Turnover: 3},
+		{Dimension: WeatherTemperature, Band: "15..20", BandOrder: 15, Samples: 1, BikesAvg: 4},
+		{Dimension: WeatherWind, Band: "light", Samples: 1, BikesAvg: 4},
+		{Dimension: WeatherWind, Band: "unknown", Samples: 1, BikesAvg: 1, Turnover: 3},
+	}
+
+	if len(rows) != len(expected) {
+		t.Fatalf("Expected %d bands but got %d", len(expected), len(rows))
+	}
+	for i, row := range rows {
+		if *row != expected[i] {
+			t.Errorf("Expected %+v but got %+v", expected[i], *row)
+		}
+	}
+}
+
+func TestRefreshStationFlows(t *testing.T) {
+	d := testPool(t)
+	from := time.Date(2001, 3, 7, 10, 0, 0, 0, time.UTC)
+	t.Cleanup(func() {
+		sql := `DELETE FROM station_flows WHERE kiosk_id = $1`
+		if _, err := d.db.Exec(sql, testKioskA); err != nil {
+			t.Errorf("Expected no error while delete station_flows, but error occur %s\n", err)
+		}
+	})
+
+	// the last snapshot comes after a gap longer than FlowMaxGap
+	for _, snapshot := range []struct {
+		at    time.Duration
+		bikes int
+	}{
+		{0, 5},
+		{30 * time.Minute, 3},
+		{70 * time.Minute, 6},
+		{70*time.Minute + FlowMaxGap + time.Minute, 0},
+	} {
+		insertSnapshot(t, d, from.Add(snapshot.at), "", testStation{KioskID: testKioskA, Bikes: snapshot.bikes, Docks: 10 - snapshot.bikes})
+	}
+
+	kioskID := testKioskA
+	query := FlowQuery{KioskID: &kioskID, From: from, To: from.Add(6 * time.Hour)}
+	check := func(t *testing.T, expected []FlowInterval) SearchResFlows {
+		result, err := d.SearchStationFlows(context.Background(), query, 5)
+		if err != nil {
+			t.Fatalf("Expected no error, but error occur %s\n", err)
+		}
+
+		if len(result.Intervals) != len(expected) {
+			t.Fatalf("Expected %d intervals but got %d", len(expected), len(result.Intervals))
+		}
+		for i, interval := range result.Intervals {
+			if !interval.Bucket.Equal(expected[i].Bucket) ||
+				interval.Departures != expected[i].Departures || interval.Arrivals != expected[i].Arrivals {
+				t.Errorf("Expected %+v but got %+v", expected[i], *interval)
+			}
+		}
+		return result
+	}
+
+	t.Run("Refresh the range", func(t *testing.T) {
+		if err := d.RefreshStationFlows(context.Background(), from, from.Add(6*time.Hour)); err != nil {
+			t.Fatalf("Expected no error, but error occur %s\n", err)
+		}
+		result := check(t, []FlowInterval{
+			{Bucket: from, Departures: 2},
+			{Bucket: from.Add(time.Hour), Arrivals: 3},
+		})
+
+		// the hours of the day are in Philadelphia, 10:00 UTC is 05:00 EST
+		expected := []FlowHour{{Hour: 6, Arrivals: 3}, {Hour: 5, Departures: 2}}
+		if len(result.BusiestHours) != len(expected) {
+			t.Fatalf("Expected %d busiest hours but got %d", len(expected), len(result.BusiestHours))
+		}
+		for i, hour := range result.BusiestHours {
+			if *hour != expected[i] {
+				t.Errorf("Expected %+v but got %+v", expected[i], *hour)
+			}
+		}
+	})
+
+	// the first flow of the hour pairs with a snapshot of the hour before
+	t.Run("Refresh one hour", func(t *testing.T) {
+		if _, err := d.db.Exec(`DELETE FROM station_flows WHERE kiosk_id = $1`, testKioskA); err != nil {
+			t.Fatalf("Expected no error while delete station_flows, but error occur %s\n", err)
+		}
+
+		at := from.Add(70 * time.Minute)
+		if err := d.RefreshStationFlows(context.Background(), at, at); err != nil {
+			t.Fatalf("Expected no error, but error occur %s\n", err)
+		}
+		check(t, []FlowInterval{{Bucket: from.Add(time.Hour), Arrivals: 3}})
+	})
+}
+
+func TestSearchForecastSamples(t *testing.T) {
+	d := testPool(t)
+
+	// daylight saving time began on Sunday April 1 2001, 08:00 in
+	// Philadelphia is 13:00 UTC before and 12:00 UTC after
+	for _, snapshot := range []struct {
+		at    time.Time
+		bikes int
+	}{
+		{time.Date(2001, 3, 25, 13, 0, 0, 0, time.UTC), 1},
+		{time.Date(2001, 3, 31, 13, 0, 0, 0, time.UTC), 2},
+		{time.Date(2001, 4, 1, 12, 30, 0, 0, time.UTC), 3},
+		{time.Date(2001, 4, 1, 13, 30, 0, 0, time.UTC), 4},
+	} {
+		insertSnapshot(t, d, snapshot.at, "", testStation{KioskID: testKioskA, Bikes: snapshot.bikes, Docks: 10 - snapshot.bikes})
+	}
+
+	rows, err := d.SearchForecastSamples(context.Background(), ForecastQuery{
+		KioskID:  testKioskA,
+		Weekday:  time.Sunday,
+		Hour:     8,
+		TimeZone: TimeZone,
+		From:     time.Date(2001, 3, 20, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2001, 4, 5, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but error occur %s\n", err)
+	}
+
+	// Saturday and 09:30 EDT are left out
+	expected := []int{1, 3}
+	if len(rows) != len(expected) {
+		t.Fatalf("Expected %d samples but got %d", len(expected), len(rows))
+	}
+	for i, row := range rows {
+		if row.BikesAvailable != expected[i] || row.MainTemp != nil {
+			t.Errorf("Expected %d bikes without weather but got %+v", expected[i], *row)
+		}
+	}
+}
+
+func TestSearchBatteries(t *testing.T) {
+	d := testPool(t)
+	from := time.Date(2001, 3, 8, 8, 0, 0, 0, time.UTC)
+
+	// the levels out of 0 to 100 are not counted
+	insertSnapshot(t, d, from, "", testStation{KioskID: testKioskA, Bikes: 5, Docks: 5, Batteries: []int{10, 30, 85, 150, -1}})
+	insertSnapshot(t, d, from.Add(time.Hour), "", testStation{KioskID: testKioskA, Bikes: 0, Docks: 10})
+
+	kioskID := testKioskA
+	result, err := d.SearchBatteries(context.Background(), BatteryQuery{
+		KioskID:      &kioskID,
+		From:         from,
+		To:           from.Add(time.Hour),
+		LowThreshold: 20,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but error occur %s\n", err)
+	}
+
+	if len(result.Stations) != 1 {
+		t.Fatalf("Expected 1 station but got %d", len(result.Stations))
+	}
+	station := result.Stations[0]
+	if station.ElectricBikes != 3 || station.LowBattery != 1 {
+		t.Errorf("Expected 3 electric bikes and 1 low battery but got %+v", *station)
+	}
+	bands := []int64{1, 1, 0, 0, 1}
+	for i, count := range station.Bands {
+		if count != bands[i] {
+			t.Errorf("Expected bands %v but got %v", bands, station.Bands)
+			break
+		}
+	}
+
+	// a snapshot without electric bikes is a point with zero bikes
+	if len(result.Trend) != 2 || result.Trend[0].ElectricBikes != 3 || result.Trend[1].ElectricBikes != 0 {
+		t.Errorf("Expected trend of 3 then 0 electric bikes but got %d points", len(result.Trend))
+	}
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// BatteryBands is the lower bound of each battery band in percent, the last
+// band goes up to 100
+var BatteryBands = []int{0, 20, 40, 60, 80}
+
+// BatteryQuery select the electric bikes of the snapshots between From and
+// To, or of the snapshot selected by Snapshot when it is not nil. Bikes
+// with battery below LowThreshold are counted as low
+type BatteryQuery struct {
+	Snapshot     *SnapshotQuery
+	KioskID      *int
+	From         time.Time
+	To           time.Time
+	LowThreshold int
+}
+
+// StationBatteries is the electric bikes of one station summed over the
+// snapshots, Bands has one count per BatteryBands
+type StationBatteries struct {
+	KioskID       int           `db:"kiosk_id"`
+	Name          string        `db:"name"`
+	ElectricBikes int           `db:"electric_bikes"`
+	LowBattery    int           `db:"low_battery"`
+	AvgBattery    float64       `db:"avg_battery"`
+	Bands         pq.Int64Array `db:"bands"`
+}
+
+// BatteryPoint is the electric bikes of all stations on one snapshot
+type BatteryPoint struct {
+	At            time.Time `db:"at"`
+	ElectricBikes int       `db:"electric_bikes"`
+	LowBattery    int       `db:"low_battery"`
+	AvgBattery    float64   `db:"avg_battery"`
+}
+
+// SearchResBatteries is the batteries of the snapshots between From and To,
+// Trend has one point per snapshot
+type SearchResBatteries struct {
+	From     time.Time
+	To       time.Time
+	Stations []*StationBatteries
+	Trend    []*BatteryPoint
+}
+
+type readBatteries struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+// readStations returns the stations with electric bikes, the most low
+// battery first. Bikes without battery level or with a level out of 0 to
+// 100 are not counted
+func (r *readBatteries) readStations(q BatteryQuery) ([]*StationBatteries, error) {
+	bands := make([]string, 0, len(BatteryBands))
+	for i, lower := range BatteryBands {
+		upper := 101
+		if i+1 < len(BatteryBands) {
+			upper = BatteryBands[i+1]
+		}
+		bands = append(bands, fmt.Sprintf("count(*) FILTER (WHERE b.battery >= %d AND b.battery < %d)", lower, upper))
+	}
+
+	sql := `SELECT p.kiosk_id, coalesce(max(p.name), '') AS name,
+			count(*) AS electric_bikes,
+			count(*) FILTER (WHERE b.battery < $4) AS low_battery,
+			avg(b.battery)::float8 AS avg_battery,
+			ARRAY[` + strings.Join(bands, ", ") + `] AS bands
+			FROM rideindego_master m
+			INNER JOIN rideindego_properties p ON p.fetch_id = m.fetch_id
+			INNER JOIN rideindego_properties_bikes b ON b.fetch_id = p.fetch_id AND b.feat_id = p.feat_id
+			WHERE m.last_update BETWEEN $1 AND $2
+			AND ($3::integer IS NULL OR p.kiosk_id = $3)
+			AND p.kiosk_id IS NOT NULL AND b.is_electric AND b.battery BETWEEN 0 AND 100
+			GROUP BY p.kiosk_id
+			ORDER BY low_battery DESC, p.kiosk_id ASC`
+
+	var rows []*StationBatteries
+	err := r.db.SelectContext(r.ctx, &rows, sql, q.From, q.To, q.KioskID, q.LowThreshold)
+	return rows, err
+}
+
+// readTrend returns one point per snapshot, oldest first. A snapshot
+// without electric bikes has a point with zero bikes. The bikes are
+// counted as in readStations
+func (r *readBatteries) readTrend(q BatteryQuery) ([]*BatteryPoint, error) {
+	sql := `SELECT m.last_update AS at,
+			count(b.battery) AS electric_bikes,
+			count(b.battery) FILTER (WHERE b.battery < $4) AS low_battery,
+			coalesce(avg(b.battery), 0)::float8 AS avg_battery
+			FROM rideindego_master m
+			LEFT OUTER JOIN (
+				rideindego_properties p
+				INNER JOIN rideindego_properties_bikes b ON b.fetch_id = p.fetch_id AND b.feat_id = p.feat_id
+					AND b.is_electric AND b.battery BETWEEN 0 AND 100
+			) ON p.fetch_id = m.fetch_id AND ($3::integer IS NULL OR p.kiosk_id = $3)
+			WHERE m.last_update BETWEEN $1 AND $2
+			GROUP BY m.last_update
+			ORDER BY m.last_update ASC`
+
+	var rows []*BatteryPoint
+	err := r.db.SelectContext(r.ctx, &rows, sql, q.From, q.To, q.KioskID, q.LowThreshold)
+	return rows, err
+}
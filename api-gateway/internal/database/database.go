@@ -9,17 +9,49 @@ import (
 
 	"github.com/arthben/BackendGolang/api-gateway/internal/config"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
-
-	_ "github.com/lib/pq"
 )
 
+// ErrSnapshotExists returned by store functions when the snapshot
+// already stored before, so the caller can treat it as no-op
+var ErrSnapshotExists = errors.New("snapshot already exists")
+
 type DBService interface {
 	Close() error
-	StoreRideIndego(context.Context, ParamStoreRideIndego) error
-	SearchRideIndego(ctx context.Context, at time.Time, kioskID string) (SearchResRideIndego, error)
-	StoreOpenWeather(ctx context.Context, master *OpenWeatherMaster, details []*OpenWewatherDetail) error
-	SearchOpenWeather(ctx context.Context, at time.Time) (SearchResOpenWeather, error)
+	StoreRideIndego(context.Context, ParamStoreRideIndego) (string, error)
+	SearchRideIndego(ctx context.Context, q SnapshotQuery, kioskID string, filter PropertiesFilter) (SearchResRideIndego, error)
+	SearchRideIndegoMaster(ctx context.Context, q SnapshotQuery) (*RideIndegoMaster, error)
+	SearchNearbyStations(ctx context.Context, q SnapshotQuery, nearby NearbyQuery) (SearchResNearby, error)
+	SearchStationHistory(ctx context.Context, kioskID int, from, to time.Time, interval time.Duration, limit int) ([]*StationHistory, error)
+	StoreOpenWeather(context.Context, ParamStoreOpenWeather) error
+	SearchOpenWeather(ctx context.Context, q SnapshotQuery) (SearchResOpenWeather, error)
+	StoreRawPayload(context.Context, *RawPayload) error
+	SearchRawPayload(ctx context.Context, fetchID string) (*RawPayload, error)
+	ListRawPayloads(ctx context.Context, source string, from, to time.Time) ([]*RawPayload, error)
+	ReadRideIndego(ctx context.Context, fetchID string) (ParamStoreRideIndego, error)
+	ReplaceRideIndego(context.Context, ParamStoreRideIndego) error
+	ReadOpenWeather(ctx context.Context, fetchID string) (ParamStoreOpenWeather, error)
+	ReplaceOpenWeather(context.Context, ParamStoreOpenWeather) error
+	StoreIngestionJob(context.Context, *IngestionJob) error
+	UpdateIngestionJob(context.Context, *IngestionJob) error
+	SearchIngestionJob(ctx context.Context, jobID string) (*IngestionJob, error)
+	RecoverIngestionJobs(context.Context) ([]string, error)
+	StoreIngestionRun(context.Context, *IngestionRun) error
+	SearchIngestionRuns(ctx context.Context, source string, from, to time.Time) ([]*IngestionRun, error)
+	LatestIngestionRuns(ctx context.Context, outcomes ...string) ([]*IngestionRun, error)
+	RefreshStationRollups(ctx context.Context, from, to time.Time) error
+	SearchStationRollups(ctx context.Context, granularity string, kioskID int, from, to time.Time, limit int) ([]*StationRollup, error)
+	SearchSystemRollups(ctx context.Context, granularity string, from, to time.Time, limit int) ([]*SystemRollup, error)
+	SearchUtilization(ctx context.Context, q UtilizationQuery) ([]*StationUtilization, error)
+	SearchWeatherImpact(ctx context.Context, q WeatherImpactQuery) ([]*WeatherImpact, error)
+	RefreshStationFlows(ctx context.Context, from, to time.Time) error
+	SearchStationFlows(ctx context.Context, q FlowQuery, busiestHours int) (SearchResFlows, error)
+	SearchForecastSamples(ctx context.Context, q ForecastQuery) ([]*ForecastSample, error)
+	SearchAnomalySamples(ctx context.Context, at time.Time, snapshots int) ([]*AnomalySample, error)
+	SearchStationAnomalies(ctx context.Context, q AnomalyQuery) ([]*StationAnomaly, error)
+	SaveStationAnomalies(context.Context, []*StationAnomaly) error
+	SearchBatteries(ctx context.Context, q BatteryQuery) (SearchResBatteries, error)
 }
 
 type dbase struct {
@@ -43,14 +75,18 @@ func (d *dbase) Close() error {
 	return d.db.Close()
 }
 
+// SearchOpenWeather find the weather of the Indego snapshot with last_update
+// equal to q.At. When the snapshot has no linked weather, the observation
+// is selected by q.Mode and q.MaxAge
 func (d *dbase) SearchOpenWeather(
 	ctx context.Context,
-	at time.Time,
+	q SnapshotQuery,
 ) (searchResult SearchResOpenWeather, err error) {
 	findme := readOpenWeather{db: d.db, ctx: ctx}
-	searchResult.Master, err = findme.readMaster(at)
+	searchResult.Master, err = findme.readMaster(q)
 	if err != nil {
 		handleError("readMaster", err)
+		return
 	}
 
 	searchResult.Detail, err = findme.readDetail(searchResult.Master.FetchID)
@@ -63,8 +99,9 @@ func (d *dbase) SearchOpenWeather(
 
 func (d *dbase) SearchRideIndego(
 	ctx context.Context,
-	at time.Time,
+	q SnapshotQuery,
 	kioskID string,
+	filter PropertiesFilter,
 ) (searchResult SearchResRideIndego, err error) {
 
 	var (
@@ -81,7 +118,7 @@ func (d *dbase) SearchRideIndego(
 		}
 
 		masterExtended := RideIndegoMasterExtends{}
-		if err = findme.readMaster(at, kiosk, &masterExtended); err != nil {
+		if err = findme.readMaster(q, kiosk, &masterExtended); err != nil {
 			handleError("readMaster", err)
 			return
 		}
@@ -95,7 +132,7 @@ func (d *dbase) SearchRideIndego(
 
 	} else {
 		master := RideIndegoMaster{}
-		if err = findme.readMaster(at, kiosk, &master); err != nil {
+		if err = findme.readMaster(q, kiosk, &master); err != nil {
 			return
 		}
 		searchResult.Master = &master
@@ -105,12 +142,14 @@ func (d *dbase) SearchRideIndego(
 	searchResult.Features, err = findme.readFeatures(searchResult.Master.FetchID, featureID)
 	if err != nil {
 		handleError("readFeatures", err)
+		return
 	}
 
 	// find properties data
-	searchResult.Properties, err = findme.readProperties(searchResult.Master.FetchID, featureID)
+	searchResult.Properties, searchResult.Total, err = findme.readProperties(searchResult.Master.FetchID, featureID, filter)
 	if err != nil {
 		handleError("readProperties", err)
+		return
 	}
 
 	// find properti bikes
@@ -122,7 +161,21 @@ func (d *dbase) SearchRideIndego(
 	return
 }
 
-func (d *dbase) StoreRideIndego(ctx context.Context, pInput ParamStoreRideIndego) (err error) {
+// SearchRideIndegoMaster find the snapshot selected by q, without its
+// stations
+func (d *dbase) SearchRideIndegoMaster(ctx context.Context, q SnapshotQuery) (*RideIndegoMaster, error) {
+	findme := readRideIndego{db: d.db, ctx: ctx}
+
+	master := RideIndegoMaster{}
+	if err := findme.readMaster(q, -1, &master); err != nil {
+		return nil, err
+	}
+	return &master, nil
+}
+
+// StoreRideIndego returns fetch_id of the stored snapshot. When the snapshot
+// already stored, it returns fetch_id of the existing one and ErrSnapshotExists
+func (d *dbase) StoreRideIndego(ctx context.Context, pInput ParamStoreRideIndego) (fetchID string, err error) {
 
 	tx, err := d.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -136,7 +189,30 @@ func (d *dbase) StoreRideIndego(ctx context.Context, pInput ParamStoreRideIndego
 	}()
 
 	storeData := storeRideIndeGo{tx: tx, ctx: ctx}
+
+	// upstream keep the same last_updated until the data changed
+	fetchID, err = storeData.findMaster(pInput.Master.LastUpdate)
+	if err != nil {
+		handleError("findMaster", err)
+		return
+	}
+	if len(fetchID) > 0 {
+		err = ErrSnapshotExists
+		return
+	}
+
 	if err = storeData.insertMaster(pInput.Master); err != nil {
+		if isUniqueViolation(err) {
+			// stored by a concurrent run after findMaster, the transaction
+			// is aborted so look for it in a new one
+			_ = tx.Rollback()
+			if fetchID, err = d.findRideIndego(ctx, pInput.Master.LastUpdate); err != nil {
+				handleError("findRideIndego", err)
+				return
+			}
+			err = ErrSnapshotExists
+			return
+		}
 		handleError("insertMaster", err)
 		return
 	}
@@ -152,11 +228,28 @@ func (d *dbase) StoreRideIndego(ctx context.Context, pInput ParamStoreRideIndego
 		handleError("insertPropertiesBike", err)
 		return
 	}
+	if err = insertRawPayload(ctx, tx, pInput.Raw); err != nil {
+		handleError("insertRawPayload", err)
+		return
+	}
 	if err = tx.Commit(); err != nil {
 		return
 	}
 
-	return nil
+	return pInput.Master.FetchID, nil
+}
+
+// findRideIndego returns fetch_id of the snapshot with lastUpdate, or empty
+// when there is none
+func (d *dbase) findRideIndego(ctx context.Context, lastUpdate time.Time) (string, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	findme := storeRideIndeGo{tx: tx, ctx: ctx}
+	return findme.findMaster(lastUpdate)
 }
 
 func handleError(msg string, err error) {
@@ -164,7 +257,61 @@ func handleError(msg string, err error) {
 	fmt.Printf("%v - err: %v\n", msg, err)
 }
 
-func (d *dbase) StoreOpenWeather(ctx context.Context, master *OpenWeatherMaster, detail []*OpenWewatherDetail) (err error) {
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// SearchNearbyStations find the snapshot selected by q and its stations
+// around the point of nearby, the nearest first
+func (d *dbase) SearchNearbyStations(
+	ctx context.Context,
+	q SnapshotQuery,
+	nearby NearbyQuery,
+) (searchResult SearchResNearby, err error) {
+	findme := readRideIndego{db: d.db, ctx: ctx}
+
+	master := RideIndegoMaster{}
+	if err = findme.readMaster(q, -1, &master); err != nil {
+		return
+	}
+	searchResult.Master = &master
+
+	if searchResult.Properties, err = findme.readNearby(master.FetchID, nearby); err != nil {
+		handleError("readNearby", err)
+		return
+	}
+
+	if searchResult.PropertiesBikes, err = findme.readPropBikes(master.FetchID, -1); err != nil {
+		handleError("readPropertiesBikes", err)
+	}
+
+	return
+}
+
+// SearchStationHistory returns the availability of one station between
+// from and to, downsampled when interval is not zero
+func (d *dbase) SearchStationHistory(
+	ctx context.Context,
+	kioskID int,
+	from time.Time,
+	to time.Time,
+	interval time.Duration,
+	limit int,
+) ([]*StationHistory, error) {
+	findme := readRideIndego{db: d.db, ctx: ctx}
+	points, err := findme.readHistory(kioskID, from, to, interval, limit)
+	if err != nil {
+		handleError("readHistory", err)
+	}
+	return points, err
+}
+
+// StoreOpenWeather returns ErrSnapshotExists when the observation with the same
+// dt and city ID already stored. The stored weather is linked to the Indego
+// snapshot with fetch_id SnapshotID when that snapshot has no weather yet,
+// empty SnapshotID means no link
+func (d *dbase) StoreOpenWeather(ctx context.Context, pInput ParamStoreOpenWeather) (err error) {
 	tx, err := d.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return
@@ -177,14 +324,39 @@ func (d *dbase) StoreOpenWeather(ctx context.Context, master *OpenWeatherMaster,
 	}()
 
 	storeData := storeWeather{tx: tx, ctx: ctx}
-	if err = storeData.insertMaster(master); err != nil {
+
+	exists, err := storeData.masterExists(pInput.Master.DT, pInput.Master.ID)
+	if err != nil {
+		handleError("masterExists", err)
+		return
+	}
+	if exists {
+		err = ErrSnapshotExists
+		return
+	}
+
+	if err = storeData.insertMaster(pInput.Master); err != nil {
+		if isUniqueViolation(err) {
+			err = ErrSnapshotExists
+			return
+		}
 		handleError("inserMaster", err)
 		return
 	}
-	if err = storeData.insertDetail(detail); err != nil {
+	if err = storeData.insertDetail(pInput.Details); err != nil {
 		handleError("insertDetail", err)
 		return
 	}
+	if err = insertRawPayload(ctx, tx, pInput.Raw); err != nil {
+		handleError("insertRawPayload", err)
+		return
+	}
+	if len(pInput.SnapshotID) > 0 {
+		if err = storeData.linkSnapshot(pInput.Master.FetchID, pInput.SnapshotID); err != nil {
+			handleError("linkSnapshot", err)
+			return
+		}
+	}
 
 	if err = tx.Commit(); err != nil {
 		return
@@ -192,3 +364,328 @@ func (d *dbase) StoreOpenWeather(ctx context.Context, master *OpenWeatherMaster,
 
 	return nil
 }
+
+// StoreRawPayload archive the body of a fetch which stored no snapshot
+func (d *dbase) StoreRawPayload(ctx context.Context, raw *RawPayload) (err error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = insertRawPayload(ctx, tx, raw); err != nil {
+		handleError("insertRawPayload", err)
+		return
+	}
+
+	return tx.Commit()
+}
+
+func (d *dbase) SearchRawPayload(ctx context.Context, fetchID string) (*RawPayload, error) {
+	raw, err := readRawPayload(ctx, d.db, fetchID)
+	if err != nil {
+		handleError("readRawPayload", err)
+	}
+	return raw, err
+}
+
+func (d *dbase) StoreIngestionJob(ctx context.Context, job *IngestionJob) error {
+	jobs := ingestionJobs{db: d.db, ctx: ctx}
+	err := jobs.insert(job)
+	if err != nil {
+		handleError("StoreIngestionJob", err)
+	}
+	return err
+}
+
+func (d *dbase) UpdateIngestionJob(ctx context.Context, job *IngestionJob) error {
+	jobs := ingestionJobs{db: d.db, ctx: ctx}
+	err := jobs.update(job)
+	if err != nil {
+		handleError("UpdateIngestionJob", err)
+	}
+	return err
+}
+
+func (d *dbase) SearchIngestionJob(ctx context.Context, jobID string) (*IngestionJob, error) {
+	jobs := ingestionJobs{db: d.db, ctx: ctx}
+	job, err := jobs.read(jobID)
+	if err != nil {
+		handleError("SearchIngestionJob", err)
+	}
+	return job, err
+}
+
+func (d *dbase) RecoverIngestionJobs(ctx context.Context) ([]string, error) {
+	jobs := ingestionJobs{db: d.db, ctx: ctx}
+	queued, err := jobs.recover()
+	if err != nil {
+		handleError("RecoverIngestionJobs", err)
+	}
+	return queued, err
+}
+
+func (d *dbase) StoreIngestionRun(ctx context.Context, run *IngestionRun) error {
+	runs := ingestionRuns{db: d.db, ctx: ctx}
+	err := runs.insert(run)
+	if err != nil {
+		handleError("StoreIngestionRun", err)
+	}
+	return err
+}
+
+func (d *dbase) SearchIngestionRuns(
+	ctx context.Context,
+	source string,
+	from time.Time,
+	to time.Time,
+) ([]*IngestionRun, error) {
+	runs := ingestionRuns{db: d.db, ctx: ctx}
+	result, err := runs.search(source, from, to)
+	if err != nil {
+		handleError("SearchIngestionRuns", err)
+	}
+	return result, err
+}
+
+// LatestIngestionRuns returns the last run of each source, limited to the
+// given outcomes when not empty
+func (d *dbase) LatestIngestionRuns(ctx context.Context, outcomes ...string) ([]*IngestionRun, error) {
+	runs := ingestionRuns{db: d.db, ctx: ctx}
+	result, err := runs.latest(outcomes...)
+	if err != nil {
+		handleError("LatestIngestionRuns", err)
+	}
+	return result, err
+}
+
+// RefreshStationRollups rebuild the hourly and daily rollups of the hours
+// and days touched by the range from to, in one transaction
+func (d *dbase) RefreshStationRollups(ctx context.Context, from time.Time, to time.Time) (err error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rollups := stationRollups{tx: tx, ctx: ctx}
+	hourFrom := RollupBucket(RollupHour, from)
+	hourTo := RollupBucket(RollupHour, to).Add(time.Hour)
+	if err = rollups.refreshHourly(hourFrom, hourTo); err != nil {
+		handleError("refreshHourly", err)
+		return
+	}
+
+	dayFrom := RollupBucket(RollupDay, from)
+	dayTo := RollupBucket(RollupDay, to).AddDate(0, 0, 1)
+	if err = rollups.refreshDaily(dayFrom, dayTo); err != nil {
+		handleError("refreshDaily", err)
+		return
+	}
+
+	return tx.Commit()
+}
+
+func (d *dbase) SearchStationRollups(
+	ctx context.Context,
+	granularity string,
+	kioskID int,
+	from time.Time,
+	to time.Time,
+	limit int,
+) ([]*StationRollup, error) {
+	findme := readStationRollups{db: d.db, ctx: ctx}
+	rows, err := findme.readStation(granularity, kioskID, from, to, limit)
+	if err != nil {
+		handleError("SearchStationRollups", err)
+	}
+	return rows, err
+}
+
+func (d *dbase) SearchSystemRollups(
+	ctx context.Context,
+	granularity string,
+	from time.Time,
+	to time.Time,
+	limit int,
+) ([]*SystemRollup, error) {
+	findme := readStationRollups{db: d.db, ctx: ctx}
+	rows, err := findme.readSystem(granularity, from, to, limit)
+	if err != nil {
+		handleError("SearchSystemRollups", err)
+	}
+	return rows, err
+}
+
+// SearchUtilization returns the top stations by q.RankBy over the snapshots
+// between q.From and q.To
+func (d *dbase) SearchUtilization(ctx context.Context, q UtilizationQuery) ([]*StationUtilization, error) {
+	findme := readUtilization{db: d.db, ctx: ctx}
+	rows, err := findme.read(q)
+	if err != nil {
+		handleError("SearchUtilization", err)
+	}
+	return rows, err
+}
+
+// SearchWeatherImpact returns the availability of the snapshots between
+// q.From and q.To banded by each weather dimension
+func (d *dbase) SearchWeatherImpact(ctx context.Context, q WeatherImpactQuery) ([]*WeatherImpact, error) {
+	findme := readWeatherImpact{db: d.db, ctx: ctx}
+	rows, err := findme.read(q)
+	if err != nil {
+		handleError("SearchWeatherImpact", err)
+	}
+	return rows, err
+}
+
+// RefreshStationFlows rebuild the flows of the hours touched by the range
+// from to, in one transaction
+func (d *dbase) RefreshStationFlows(ctx context.Context, from time.Time, to time.Time) (err error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	flows := stationFlows{tx: tx, ctx: ctx}
+	hourFrom := RollupBucket(RollupHour, from)
+	hourTo := RollupBucket(RollupHour, to).Add(time.Hour)
+	if err = flows.refresh(hourFrom, hourTo); err != nil {
+		handleError("refreshFlows", err)
+		return
+	}
+
+	return tx.Commit()
+}
+
+// SearchStationFlows returns the flows selected by q by hour and by
+// station, and the busiestHours hours of the day of all stations
+func (d *dbase) SearchStationFlows(
+	ctx context.Context,
+	q FlowQuery,
+	busiestHours int,
+) (searchResult SearchResFlows, err error) {
+	findme := readStationFlows{db: d.db, ctx: ctx}
+
+	if searchResult.Intervals, err = findme.readIntervals(q); err != nil {
+		handleError("readIntervals", err)
+		return
+	}
+
+	if searchResult.Stations, err = findme.readStations(q); err != nil {
+		handleError("readStations", err)
+		return
+	}
+
+	if searchResult.BusiestHours, err = findme.readBusiestHours(q.From, q.To, busiestHours); err != nil {
+		handleError("readBusiestHours", err)
+	}
+
+	return
+}
+
+// SearchForecastSamples returns the snapshots of one station with their
+// weather taken on the weekday and hour of q
+func (d *dbase) SearchForecastSamples(ctx context.Context, q ForecastQuery) ([]*ForecastSample, error) {
+	findme := readForecast{db: d.db, ctx: ctx}
+	rows, err := findme.read(q)
+	if err != nil {
+		handleError("SearchForecastSamples", err)
+	}
+	return rows, err
+}
+
+// SearchAnomalySamples returns the stations of the last snapshots taken up
+// to at, ordered by station then newest first
+func (d *dbase) SearchAnomalySamples(ctx context.Context, at time.Time, snapshots int) ([]*AnomalySample, error) {
+	anomalies := stationAnomalies{db: d.db, ctx: ctx}
+	rows, err := anomalies.readSamples(at, snapshots)
+	if err != nil {
+		handleError("SearchAnomalySamples", err)
+	}
+	return rows, err
+}
+
+func (d *dbase) SearchStationAnomalies(ctx context.Context, q AnomalyQuery) ([]*StationAnomaly, error) {
+	anomalies := stationAnomalies{db: d.db, ctx: ctx}
+	rows, err := anomalies.read(q)
+	if err != nil {
+		handleError("SearchStationAnomalies", err)
+	}
+	return rows, err
+}
+
+// SaveStationAnomalies insert the anomalies without ID and update the
+// others, in one transaction
+func (d *dbase) SaveStationAnomalies(ctx context.Context, anomalies []*StationAnomaly) (err error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	storeData := saveStationAnomalies{tx: tx, ctx: ctx}
+	for _, anomaly := range anomalies {
+		if anomaly.AnomalyID == 0 {
+			err = storeData.insert(anomaly)
+		} else {
+			err = storeData.update(anomaly)
+		}
+		if err != nil {
+			handleError("SaveStationAnomalies", err)
+			return
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SearchBatteries returns the electric bikes by station and the trend of
+// the snapshots of q. When q.Snapshot is set, the range is the one snapshot
+// it selects
+func (d *dbase) SearchBatteries(ctx context.Context, q BatteryQuery) (searchResult SearchResBatteries, err error) {
+	if q.Snapshot != nil {
+		master := RideIndegoMaster{}
+		findme := readRideIndego{db: d.db, ctx: ctx}
+		if err = findme.readMaster(*q.Snapshot, -1, &master); err != nil {
+			return
+		}
+		q.From, q.To = master.LastUpdate, master.LastUpdate
+	}
+	searchResult.From, searchResult.To = q.From, q.To
+
+	findme := readBatteries{db: d.db, ctx: ctx}
+	searchResult.Stations, err = findme.readStations(q)
+	if err != nil {
+		handleError("readStations", err)
+		return
+	}
+
+	searchResult.Trend, err = findme.readTrend(q)
+	if err != nil {
+		handleError("readTrend", err)
+	}
+
+	return
+}
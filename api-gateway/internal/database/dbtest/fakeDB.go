@@ -0,0 +1,115 @@
+// Package dbtest provides a DBService kept in memory for the tests of the
+// services. The SQL itself is tested against a running database in
+// package database.
+package dbtest
+
+import (
+	"context"
+	"time"
+
+	dbase "github.com/arthben/BackendGolang/api-gateway/internal/database"
+)
+
+// FakeDB returns the rows set on its fields and keeps what it is given.
+// Calling a method not implemented here panics on the nil embedded
+// DBService
+type FakeDB struct {
+	dbase.DBService
+
+	// Runs is the runs stored by StoreIngestionRun
+	Runs []*dbase.IngestionRun
+
+	// Rollups is the number of hourly buckets returned by the rollup
+	// searches, up to their limit. Granularity is the one last searched
+	Rollups     int
+	Granularity string
+
+	// Utilization is returned by SearchUtilization, UtilizationQuery is the
+	// query it was given
+	Utilization      []*dbase.StationUtilization
+	UtilizationQuery dbase.UtilizationQuery
+
+	// WeatherImpact is returned by SearchWeatherImpact
+	WeatherImpact []*dbase.WeatherImpact
+
+	// Flows is returned by SearchStationFlows
+	Flows dbase.SearchResFlows
+
+	// ForecastSamples is returned by SearchForecastSamples, ForecastQuery
+	// is the query it was given
+	ForecastSamples []*dbase.ForecastSample
+	ForecastQuery   dbase.ForecastQuery
+
+	// Weather is the master returned by SearchOpenWeather, WeatherQuery is
+	// the query it was given
+	Weather      *dbase.OpenWeatherMaster
+	WeatherQuery dbase.SnapshotQuery
+
+	// Batteries and BatteriesErr are returned by SearchBatteries,
+	// BatteryQuery is the query it was given
+	Batteries    dbase.SearchResBatteries
+	BatteriesErr error
+	BatteryQuery dbase.BatteryQuery
+}
+
+func (d *FakeDB) StoreIngestionRun(ctx context.Context, run *dbase.IngestionRun) error {
+	d.Runs = append(d.Runs, run)
+	return nil
+}
+
+func (d *FakeDB) SearchStationRollups(
+	ctx context.Context,
+	granularity string,
+	kioskID int,
+	from, to time.Time,
+	limit int,
+) ([]*dbase.StationRollup, error) {
+	d.Granularity = granularity
+	rows := make([]*dbase.StationRollup, 0, d.Rollups)
+	for i := 0; i < d.Rollups && i < limit; i++ {
+		rows = append(rows, &dbase.StationRollup{KioskID: kioskID, Bucket: from.Add(time.Duration(i) * time.Hour), Samples: 12})
+	}
+	return rows, nil
+}
+
+func (d *FakeDB) SearchSystemRollups(
+	ctx context.Context,
+	granularity string,
+	from, to time.Time,
+	limit int,
+) ([]*dbase.SystemRollup, error) {
+	d.Granularity = granularity
+	rows := make([]*dbase.SystemRollup, 0, d.Rollups)
+	for i := 0; i < d.Rollups && i < limit; i++ {
+		rows = append(rows, &dbase.SystemRollup{Bucket: from.Add(time.Duration(i) * time.Hour), Stations: 2})
+	}
+	return rows, nil
+}
+
+func (d *FakeDB) SearchUtilization(ctx context.Context, q dbase.UtilizationQuery) ([]*dbase.StationUtilization, error) {
+	d.UtilizationQuery = q
+	return d.Utilization, nil
+}
+
+func (d *FakeDB) SearchWeatherImpact(ctx context.Context, q dbase.WeatherImpactQuery) ([]*dbase.WeatherImpact, error) {
+	return d.WeatherImpact, nil
+}
+
+func (d *FakeDB) SearchStationFlows(ctx context.Context, q dbase.FlowQuery, busiestHours int) (dbase.SearchResFlows, error) {
+	return d.Flows, nil
+}
+
+func (d *FakeDB) SearchForecastSamples(ctx context.Context, q dbase.ForecastQuery) ([]*dbase.ForecastSample, error) {
+	d.ForecastQuery = q
+	return d.ForecastSamples, nil
+}
+
+func (d *FakeDB) SearchOpenWeather(ctx context.Context, q dbase.SnapshotQuery) (dbase.SearchResOpenWeather, error) {
+	d.WeatherQuery = q
+	return dbase.SearchResOpenWeather{Master: d.Weather}, nil
+}
+
+func (d *FakeDB) SearchBatteries(ctx context.Context, q dbase.BatteryQuery) (dbase.SearchResBatteries, error) {
+	d.BatteryQuery = q
+	return d.Batteries, d.BatteriesErr
+}
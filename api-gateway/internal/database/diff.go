@@ -0,0 +1,65 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// DiffRows compare rows of a table matched by key. It returns one line
+// for each added or removed row and for each changed column
+func DiffRows[T any](table string, key func(*T) string, oldRows []*T, newRows []*T) []string {
+	var (
+		lines    []string
+		oldByKey = make(map[string]*T, len(oldRows))
+		newByKey = make(map[string]bool, len(newRows))
+	)
+
+	for _, row := range oldRows {
+		oldByKey[key(row)] = row
+	}
+
+	for _, row := range newRows {
+		k := key(row)
+		newByKey[k] = true
+
+		oldRow, ok := oldByKey[k]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("%s %s: added", table, k))
+			continue
+		}
+		lines = append(lines, diffColumns(table, k, oldRow, row)...)
+	}
+
+	for _, row := range oldRows {
+		if k := key(row); !newByKey[k] {
+			lines = append(lines, fmt.Sprintf("%s %s: removed", table, k))
+		}
+	}
+
+	return lines
+}
+
+func diffColumns(table string, key string, oldRow interface{}, newRow interface{}) []string {
+	var lines []string
+
+	oldVal := reflect.Indirect(reflect.ValueOf(oldRow))
+	newVal := reflect.Indirect(reflect.ValueOf(newRow))
+	for i := 0; i < oldVal.NumField(); i++ {
+		a, b := oldVal.Field(i).Interface(), newVal.Field(i).Interface()
+
+		// time read from database may have different location
+		if ta, ok := a.(time.Time); ok {
+			if ta.Equal(b.(time.Time)) {
+				continue
+			}
+		} else if reflect.DeepEqual(a, b) {
+			continue
+		}
+
+		column := oldVal.Type().Field(i).Tag.Get("db")
+		lines = append(lines, fmt.Sprintf("%s %s %s: %v -> %v", table, key, column, a, b))
+	}
+
+	return lines
+}
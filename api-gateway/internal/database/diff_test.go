@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffRows(t *testing.T) {
+	key := func(row *RideIndegoBikes) string {
+		return fmt.Sprintf("feat_id=%d idx=%d", row.FeatureID, row.Index)
+	}
+
+	oldRows := []*RideIndegoBikes{
+		{FeatureID: 1, Index: 0, Battery: 50, IsElectric: true},
+		{FeatureID: 1, Index: 1, Battery: 90},
+		{FeatureID: 2, Index: 0, DockNumber: 3},
+	}
+	newRows := []*RideIndegoBikes{
+		{FeatureID: 1, Index: 0, Battery: 55, IsElectric: true},
+		{FeatureID: 2, Index: 0, DockNumber: 3},
+		{FeatureID: 3, Index: 0},
+	}
+
+	expected := []string{
+		"rideindego_properties_bikes feat_id=1 idx=0 battery: 50 -> 55",
+		"rideindego_properties_bikes feat_id=3 idx=0: added",
+		"rideindego_properties_bikes feat_id=1 idx=1: removed",
+	}
+
+	lines := DiffRows("rideindego_properties_bikes", key, oldRows, newRows)
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected diff %v but got %v", expected, lines)
+	}
+}
+
+func TestDiffRowsTime(t *testing.T) {
+	key := func(row *RideIndegoMaster) string { return row.FetchID }
+
+	at := time.Date(2024, 11, 8, 1, 0, 0, 0, time.UTC)
+	oldRows := []*RideIndegoMaster{{FetchID: "a", LastUpdate: at.In(time.FixedZone("EST", -5*3600))}}
+	newRows := []*RideIndegoMaster{{FetchID: "a", LastUpdate: at}}
+
+	if lines := DiffRows("rideindego_master", key, oldRows, newRows); len(lines) != 0 {
+		t.Errorf("Expected no diff but got %v", lines)
+	}
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ForecastQuery select the snapshots of one station between From and To
+// taken on Weekday at Hour, both in TimeZone
+type ForecastQuery struct {
+	KioskID  int
+	Weekday  time.Weekday
+	Hour     int
+	TimeZone string
+	From     time.Time
+	To       time.Time
+}
+
+// ForecastSample is one snapshot of the station with its weather, MainTemp
+// and RainOneHour are nil when the snapshot has no weather
+type ForecastSample struct {
+	At             time.Time `db:"at"`
+	BikesAvailable int       `db:"bikes_available"`
+	DocksAvailable int       `db:"docks_available"`
+	TotalDocks     int       `db:"total_docks"`
+	MainTemp       *float64  `db:"main_temp"`
+	RainOneHour    *float64  `db:"rain_one_hour"`
+}
+
+type readForecast struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+// read returns the samples of q, oldest first
+func (r *readForecast) read(q ForecastQuery) ([]*ForecastSample, error) {
+	sql := `SELECT m.last_update AS at, p.bikes_available, p.docks_available,
+			coalesce(p.total_docks, 0) AS total_docks,
+			w.main_temp, w.rain_one_hour
+			FROM rideindego_master m
+			INNER JOIN rideindego_properties p ON p.fetch_id = m.fetch_id
+			` + snapshotWeather + `
+			WHERE p.kiosk_id = $1 AND m.last_update BETWEEN $2 AND $3
+			AND extract(dow FROM m.last_update AT TIME ZONE $6) = $4
+			AND extract(hour FROM m.last_update AT TIME ZONE $6) = $5
+			AND p.bikes_available IS NOT NULL AND p.docks_available IS NOT NULL
+			ORDER BY m.last_update ASC`
+
+	var rows []*ForecastSample
+	err := r.db.SelectContext(r.ctx, &rows, sql, q.KioskID, q.From, q.To, int(q.Weekday), q.Hour, q.TimeZone)
+	return rows, err
+}
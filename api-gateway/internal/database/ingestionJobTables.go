@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Status of an ingestion job, JobPartial when only some of the sources
+// failed
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobPartial   = "partial"
+	JobFailed    = "failed"
+)
+
+// Structure table ingestion_jobs. Result is the JSON of per source result
+type IngestionJob struct {
+	JobID      string     `db:"job_id"`
+	Status     string     `db:"status"`
+	CreatedAt  time.Time  `db:"created_at"`
+	StartedAt  *time.Time `db:"started_at"`
+	FinishedAt *time.Time `db:"finished_at"`
+	Result     string     `db:"result"`
+}
+
+type ingestionJobs struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+func (j *ingestionJobs) insert(job *IngestionJob) error {
+	sql := `INSERT INTO ingestion_jobs
+			(job_id, status, created_at)
+			VALUES
+			(:job_id, :status, :created_at)`
+	_, err := j.db.NamedExecContext(j.ctx, sql, job)
+	return err
+}
+
+func (j *ingestionJobs) update(job *IngestionJob) error {
+	sql := `UPDATE ingestion_jobs SET
+			status = :status, started_at = :started_at, finished_at = :finished_at, 
+			result = NULLIF(:result, '')::jsonb
+			WHERE job_id = :job_id`
+	_, err := j.db.NamedExecContext(j.ctx, sql, job)
+	return err
+}
+
+func (j *ingestionJobs) read(jobID string) (*IngestionJob, error) {
+	sql := `SELECT job_id, status, created_at, started_at, finished_at, 
+			COALESCE(result::text, '') AS result
+			FROM ingestion_jobs
+			WHERE job_id = $1`
+
+	var job IngestionJob
+	err := j.db.GetContext(j.ctx, &job, sql, jobID)
+	return &job, err
+}
+
+// recover fail the jobs which were running when the process stopped and
+// returns ID of the queued jobs, oldest first
+func (j *ingestionJobs) recover() ([]string, error) {
+	sql := `UPDATE ingestion_jobs 
+			SET status = $1, finished_at = now(), 
+				result = jsonb_build_object('error', 'interrupted by restart')
+			WHERE status = $2`
+	if _, err := j.db.ExecContext(j.ctx, sql, JobFailed, JobRunning); err != nil {
+		return nil, err
+	}
+
+	var queued []string
+	sql = `SELECT job_id FROM ingestion_jobs WHERE status = $1 ORDER BY created_at`
+	err := j.db.SelectContext(j.ctx, &queued, sql, JobQueued)
+	return queued, err
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// maxIngestionRuns limit the rows returned by SearchIngestionRuns
+const maxIngestionRuns = 1000
+
+// Structure table ingestion_runs. One row for each fetch and store of
+// one source. FetchID is empty when nothing stored
+type IngestionRun struct {
+	RunID          string    `db:"run_id"`
+	Source         string    `db:"source"`
+	StartedAt      time.Time `db:"started_at"`
+	FinishedAt     time.Time `db:"finished_at"`
+	Outcome        string    `db:"outcome"`
+	UpstreamStatus int       `db:"upstream_status"`
+	FetchID        string    `db:"fetch_id"`
+	Features       int       `db:"features"`
+	Properties     int       `db:"properties"`
+	Bikes          int       `db:"bikes"`
+	TotalRows      int       `db:"total_rows"`
+	Error          string    `db:"error"`
+}
+
+type ingestionRuns struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+const ingestionRunColumns = `run_id, source, started_at, finished_at, outcome, upstream_status,
+			COALESCE(fetch_id::text, '') AS fetch_id, features, properties, bikes, total_rows, error`
+
+func (r *ingestionRuns) insert(run *IngestionRun) error {
+	sql := `INSERT INTO ingestion_runs
+			(run_id, source, started_at, finished_at, outcome, upstream_status, 
+			fetch_id, features, properties, bikes, total_rows, error)
+			VALUES
+			(:run_id, :source, :started_at, :finished_at, :outcome, :upstream_status, 
+			NULLIF(:fetch_id, '')::uuid, :features, :properties, :bikes, :total_rows, :error)`
+	_, err := r.db.NamedExecContext(r.ctx, sql, run)
+	return err
+}
+
+// search returns the runs started between from and to, newest first.
+// Empty source means all sources
+func (r *ingestionRuns) search(source string, from time.Time, to time.Time) ([]*IngestionRun, error) {
+	sql := `SELECT ` + ingestionRunColumns + `
+			FROM ingestion_runs
+			WHERE started_at BETWEEN $1 AND $2
+			AND ($3 = '' OR source = $3)
+			ORDER BY started_at DESC
+			LIMIT $4`
+
+	var runs []*IngestionRun
+	err := r.db.SelectContext(r.ctx, &runs, sql, from, to, source, maxIngestionRuns)
+	return runs, err
+}
+
+// latest returns the last run of each source. When outcomes is not empty
+// only runs with one of those outcomes are considered
+func (r *ingestionRuns) latest(outcomes ...string) ([]*IngestionRun, error) {
+	sql := `SELECT DISTINCT ON (source) ` + ingestionRunColumns + `
+			FROM ingestion_runs
+			WHERE (cardinality($1::varchar[]) = 0 OR outcome = ANY($1))
+			ORDER BY source, started_at DESC`
+
+	var runs []*IngestionRun
+	err := r.db.SelectContext(r.ctx, &runs, sql, pq.Array(outcomes))
+	return runs, err
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchIngestionRuns(t *testing.T) {
+	d := testPool(t)
+	ctx := context.Background()
+	from := time.Date(2001, 3, 5, 8, 0, 0, 0, time.UTC)
+
+	// a stored run of each source and an Indego run which failed
+	stored := uuid.New().String()
+	runs := []*IngestionRun{
+		{RunID: uuid.New().String(), Source: RawSourceRideIndego, StartedAt: from,
+			Outcome: "stored", FetchID: stored, Features: 2, TotalRows: 5},
+		{RunID: uuid.New().String(), Source: RawSourceOpenWeather, StartedAt: from.Add(time.Minute),
+			Outcome: "stored"},
+		{RunID: uuid.New().String(), Source: RawSourceRideIndego, StartedAt: from.Add(time.Hour),
+			Outcome: "failed", Error: "timeout"},
+	}
+	for _, run := range runs {
+		run.FinishedAt = run.StartedAt.Add(time.Second)
+		if err := d.StoreIngestionRun(ctx, run); err != nil {
+			t.Fatalf("Expected no error while store run, but error occur %s\n", err)
+		}
+		runID := run.RunID
+		t.Cleanup(func() {
+			if _, err := d.db.Exec(`DELETE FROM ingestion_runs WHERE run_id = $1`, runID); err != nil {
+				t.Errorf("Expected no error while delete run, but error occur %s\n", err)
+			}
+		})
+	}
+
+	scenarios := []struct {
+		name     string
+		source   string
+		expected []string
+	}{
+		{name: "all sources, newest first", source: "",
+			expected: []string{runs[2].RunID, runs[1].RunID, runs[0].RunID}},
+		{name: "one source", source: RawSourceRideIndego,
+			expected: []string{runs[2].RunID, runs[0].RunID}},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			result, err := d.SearchIngestionRuns(ctx, scenario.source, from, from.Add(2*time.Hour))
+			if err != nil {
+				t.Fatalf("Expected no error, but error occur %s\n", err)
+			}
+			if len(result) != len(scenario.expected) {
+				t.Fatalf("Expected %d runs but got %d\n", len(scenario.expected), len(result))
+			}
+			for i, runID := range scenario.expected {
+				if result[i].RunID != runID {
+					t.Errorf("Expected run %d to be %s but got %s\n", i, runID, result[i].RunID)
+				}
+			}
+		})
+	}
+
+	// fetch_id NULL is read back empty
+	result, err := d.SearchIngestionRuns(ctx, RawSourceRideIndego, from, from)
+	if err != nil {
+		t.Fatalf("Expected no error, but error occur %s\n", err)
+	}
+	if len(result) != 1 || result[0].FetchID != stored || result[0].TotalRows != 5 {
+		t.Errorf("Expected the stored run with fetch_id %s but got %+v\n", stored, result)
+	}
+	result, err = d.SearchIngestionRuns(ctx, RawSourceRideIndego, from.Add(time.Hour), from.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, but error occur %s\n", err)
+	}
+	if len(result) != 1 || result[0].FetchID != "" || result[0].Error != "timeout" {
+		t.Errorf("Expected the failed run without fetch_id but got %+v\n", result)
+	}
+}
@@ -2,11 +2,18 @@ package database
 
 import (
 	"context"
-	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// Parameter for store data
+type ParamStoreOpenWeather struct {
+	Master     *OpenWeatherMaster
+	Details    []*OpenWewatherDetail
+	SnapshotID string
+	Raw        *RawPayload
+}
+
 type SearchResOpenWeather struct {
 	Master *OpenWeatherMaster
 	Detail []*OpenWewatherDetail
@@ -55,25 +62,59 @@ type readOpenWeather struct {
 	ctx context.Context
 }
 
-func (r *readOpenWeather) readMaster(at time.Time) (*OpenWeatherMaster, error) {
+// readMaster returns the weather linked to the Indego snapshot with
+// last_update q.At, otherwise the observation selected by q.Mode. Empty
+// mode prefers the latest observation on or before q.At, then the first
+// one after it
+func (r *readOpenWeather) readMaster(q SnapshotQuery) (*OpenWeatherMaster, error) {
+	order := q.orderBy("w.dt", "abs(w.dt - $4)")
+	if len(q.Mode) == 0 {
+		q.Mode = ModeNearest
+		order = "CASE WHEN w.dt <= $4 THEN 0 ELSE 1 END, abs(w.dt - $4)"
+	}
+	from, to := q.bounds()
+
+	args := []interface{}{q.At, from.Unix(), to.Unix()}
+	if q.Mode == ModeNearest {
+		args = append(args, q.At.Unix())
+	}
+
+	sql := `SELECT w.fetch_id, w.base, w.clouds, w.cod, ST_AsText(w.coord) AS coord, w.dt, w.id, 
+			w.main_feels_like, w.main_grnd_level, w.main_humidity, w.main_pressure, 
+			w.main_sea_level, w.main_temp, w.main_temp_max, w.main_temp_min, w."name", 
+			w.rain_one_hour, w.sys_country, w.sys_id, w.sys_sunrise, w.sys_sunset, 
+			w.sys_type, w.timezone, w.visibility, w.wind_deg, w.wind_speed
+			FROM openweather_master w
+			LEFT OUTER JOIN rideindego_master m 
+				ON m.weather_fetch_id = w.fetch_id AND m.last_update = $1
+			WHERE m.fetch_id IS NOT NULL OR w.dt BETWEEN $2 AND $3
+			ORDER BY m.fetch_id IS NULL, ` + order + `
+			LIMIT 1`
+
+	var master OpenWeatherMaster
+	err := r.db.GetContext(r.ctx, &master, sql, args...)
+	return &master, err
+}
+
+func (r *readOpenWeather) readMasterByID(fetchID string) (*OpenWeatherMaster, error) {
 	sql := `SELECT fetch_id, base, clouds, cod, ST_AsText(coord) AS coord, dt, id, 
 			main_feels_like, main_grnd_level, main_humidity, main_pressure, 
 			main_sea_level, main_temp, main_temp_max, main_temp_min, "name", 
 			rain_one_hour, sys_country, sys_id, sys_sunrise, sys_sunset, 
 			sys_type, timezone, visibility, wind_deg, wind_speed
 			FROM openweather_master
-			WHERE dt >= $1
-			LIMIT 1`
+			WHERE fetch_id = $1`
 
 	var master OpenWeatherMaster
-	err := r.db.GetContext(r.ctx, &master, sql, at.Unix())
+	err := r.db.GetContext(r.ctx, &master, sql, fetchID)
 	return &master, err
 }
 
 func (r *readOpenWeather) readDetail(fetchID string) ([]*OpenWewatherDetail, error) {
 	sql := `SELECT fetch_id, idx, description, icon, id, main
 			FROM openweather_weather
-			WHERE fetch_id = $1`
+			WHERE fetch_id = $1
+			ORDER BY idx`
 
 	var detail []*OpenWewatherDetail
 	err := r.db.SelectContext(r.ctx, &detail, sql, fetchID)
@@ -85,6 +126,16 @@ type storeWeather struct {
 	ctx context.Context
 }
 
+func (s *storeWeather) masterExists(dt, cityID int) (bool, error) {
+	sql := `SELECT EXISTS (
+				SELECT 1 FROM openweather_master WHERE dt = $1 AND id = $2
+			)`
+
+	var exists bool
+	err := s.tx.GetContext(s.ctx, &exists, sql, dt, cityID)
+	return exists, err
+}
+
 func (s *storeWeather) insertMaster(master *OpenWeatherMaster) error {
 	sql := `INSERT INTO openweather_master
 			(fetch_id, base, clouds, cod, coord, dt, id, main_feels_like, 
@@ -103,7 +154,34 @@ func (s *storeWeather) insertMaster(master *OpenWeatherMaster) error {
 	return err
 }
 
+func (s *storeWeather) updateMaster(master *OpenWeatherMaster) error {
+	sql := `UPDATE openweather_master SET 
+			base = :base, clouds = :clouds, cod = :cod, 
+			coord = ST_GeomFromText(:coord, 4326), dt = :dt, id = :id, 
+			main_feels_like = :main_feels_like, main_grnd_level = :main_grnd_level, 
+			main_humidity = :main_humidity, main_pressure = :main_pressure, 
+			main_sea_level = :main_sea_level, main_temp = :main_temp, 
+			main_temp_max = :main_temp_max, main_temp_min = :main_temp_min, 
+			"name" = :name, rain_one_hour = :rain_one_hour, 
+			sys_country = :sys_country, sys_id = :sys_id, sys_sunrise = :sys_sunrise, 
+			sys_sunset = :sys_sunset, sys_type = :sys_type, timezone = :timezone, 
+			visibility = :visibility, wind_deg = :wind_deg, wind_speed = :wind_speed
+			WHERE fetch_id = :fetch_id`
+	_, err := s.tx.NamedExecContext(s.ctx, sql, master)
+	return err
+}
+
+func (s *storeWeather) deleteDetail(fetchID string) error {
+	sql := `DELETE FROM openweather_weather WHERE fetch_id = $1`
+	_, err := s.tx.ExecContext(s.ctx, sql, fetchID)
+	return err
+}
+
 func (s *storeWeather) insertDetail(detail []*OpenWewatherDetail) error {
+	if len(detail) == 0 {
+		return nil
+	}
+
 	sql := `INSERT INTO openweather_weather
 			(fetch_id, description, icon, id, main)
 			VALUES
@@ -111,3 +189,12 @@ func (s *storeWeather) insertDetail(detail []*OpenWewatherDetail) error {
 	_, err := s.tx.NamedExecContext(s.ctx, sql, detail)
 	return err
 }
+
+// linkSnapshot link the weather to the snapshot, a snapshot already linked
+// keeps its weather
+func (s *storeWeather) linkSnapshot(fetchID string, snapshotID string) error {
+	sql := `UPDATE rideindego_master SET weather_fetch_id = $1
+			WHERE fetch_id = $2 AND weather_fetch_id IS NULL`
+	_, err := s.tx.ExecContext(s.ctx, sql, fetchID, snapshotID)
+	return err
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// numeric columns of rideindego_properties which can be used to sort
+var sortableProperties = map[string]bool{
+	"id":                       true,
+	"kiosk_id":                 true,
+	"kiosk_type":               true,
+	"latitude":                 true,
+	"longitude":                true,
+	"total_docks":              true,
+	"bikes_available":          true,
+	"docks_available":          true,
+	"trikes_available":         true,
+	"smart_bikes_available":    true,
+	"reward_bikes_available":   true,
+	"reward_docks_available":   true,
+	"classic_bikes_available":  true,
+	"electric_bikes_available": true,
+}
+
+// PropertiesFilter filter, sort and paginate the stations of a snapshot.
+// Nil and empty fields are not filtered, Limit zero means no limit
+type PropertiesFilter struct {
+	KioskStatus string
+	MinBikes    *int
+	MinDocks    *int
+	IsVirtual   *bool
+	Zip         string
+	KioskType   *int
+	KioskIDs    []int
+
+	// Area is GeoJSON Polygon or MultiPolygon, the station geometry of
+	// rideindego_features must be inside
+	Area string
+
+	// Sort is a column of rideindego_properties, see SortableProperty
+	Sort   string
+	Desc   bool
+	Limit  int
+	Offset int
+}
+
+func SortableProperty(column string) bool {
+	return sortableProperties[column]
+}
+
+// where returns the conditions of the filter starting with AND, the
+// values are appended to args
+func (f PropertiesFilter) where(args *[]interface{}) string {
+	var sql strings.Builder
+
+	add := func(condition string, value interface{}) {
+		*args = append(*args, value)
+		fmt.Fprintf(&sql, " AND %s $%d", condition, len(*args))
+	}
+
+	if len(f.KioskStatus) > 0 {
+		add("kiosk_status =", f.KioskStatus)
+	}
+	if f.MinBikes != nil {
+		add("bikes_available >=", *f.MinBikes)
+	}
+	if f.MinDocks != nil {
+		add("docks_available >=", *f.MinDocks)
+	}
+	if f.IsVirtual != nil {
+		add("is_virtual =", *f.IsVirtual)
+	}
+	if len(f.Zip) > 0 {
+		add("address_zip_code =", f.Zip)
+	}
+	if f.KioskType != nil {
+		add("kiosk_type =", *f.KioskType)
+	}
+	if len(f.KioskIDs) > 0 {
+		kioskIDs := make([]int64, 0, len(f.KioskIDs))
+		for _, id := range f.KioskIDs {
+			kioskIDs = append(kioskIDs, int64(id))
+		}
+
+		*args = append(*args, pq.Array(kioskIDs))
+		fmt.Fprintf(&sql, " AND kiosk_id = ANY($%d)", len(*args))
+	}
+	if len(f.Area) > 0 {
+		*args = append(*args, f.Area)
+		fmt.Fprintf(&sql, ` AND feat_id IN (
+				SELECT f.feat_id FROM rideindego_features f
+				WHERE f.fetch_id = rideindego_properties.fetch_id
+				AND ST_Intersects(f.geo_coordinate, ST_SetSRID(ST_GeomFromGeoJSON($%d), 4326)))`, len(*args))
+	}
+
+	return sql.String()
+}
+
+// orderBy returns ORDER BY and LIMIT of the filter, feat_id keep the
+// order stable between pages
+func (f PropertiesFilter) orderBy(args *[]interface{}) string {
+	sql := " ORDER BY feat_id"
+	if SortableProperty(f.Sort) {
+		direction := "ASC"
+		if f.Desc {
+			direction = "DESC"
+		}
+		sql = fmt.Sprintf(" ORDER BY %s %s, feat_id", f.Sort, direction)
+	}
+
+	if f.Limit > 0 {
+		*args = append(*args, f.Limit, f.Offset)
+		sql += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(*args)-1, len(*args))
+	}
+
+	return sql
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestPropertiesFilterSQL(t *testing.T) {
+	minBikes, isVirtual := 3, false
+
+	scenarios := []struct {
+		name          string
+		filter        PropertiesFilter
+		expectedWhere string
+		expectedOrder string
+		expectedArgs  []interface{}
+	}{
+		{
+			name:          "No filter",
+			filter:        PropertiesFilter{},
+			expectedOrder: " ORDER BY feat_id",
+			expectedArgs:  []interface{}{"fetch"},
+		},
+		{
+			name: "Filter sort and page",
+			filter: PropertiesFilter{
+				KioskStatus: "Active",
+				MinBikes:    &minBikes,
+				IsVirtual:   &isVirtual,
+				Sort:        "bikes_available",
+				Desc:        true,
+				Limit:       10,
+				Offset:      20,
+			},
+			expectedWhere: " AND kiosk_status = $2 AND bikes_available >= $3 AND is_virtual = $4",
+			expectedOrder: " ORDER BY bikes_available DESC, feat_id LIMIT $5 OFFSET $6",
+			expectedArgs:  []interface{}{"fetch", "Active", 3, false, 10, 20},
+		},
+		{
+			name:          "Kiosk IDs",
+			filter:        PropertiesFilter{KioskIDs: []int{3005, 3006}},
+			expectedWhere: " AND kiosk_id = ANY($2)",
+			expectedOrder: " ORDER BY feat_id",
+			expectedArgs:  []interface{}{"fetch", pq.Array([]int64{3005, 3006})},
+		},
+		{
+			name:          "Unknown sort column is ignored",
+			filter:        PropertiesFilter{Sort: "name; DROP TABLE rideindego_properties"},
+			expectedOrder: " ORDER BY feat_id",
+			expectedArgs:  []interface{}{"fetch"},
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			args := []interface{}{"fetch"}
+			where := ts.filter.where(&args)
+			order := ts.filter.orderBy(&args)
+
+			if where != ts.expectedWhere {
+				t.Errorf("Expected where %q but got %q", ts.expectedWhere, where)
+			}
+			if order != ts.expectedOrder {
+				t.Errorf("Expected order %q but got %q", ts.expectedOrder, order)
+			}
+			if !reflect.DeepEqual(args, ts.expectedArgs) {
+				t.Errorf("Expected args %v but got %v", ts.expectedArgs, args)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	RawSourceRideIndego  = "rideindego"
+	RawSourceOpenWeather = "openweather"
+)
+
+// Structure table raw_payloads. Body is the upstream response body as is,
+// it is stored gzip compressed. FetchID is the one of the snapshot stored by
+// the fetch, or a fetch_id of its own when the snapshot was unchanged
+type RawPayload struct {
+	FetchID   string    `db:"fetch_id"`
+	Source    string    `db:"source"`
+	FetchedAt time.Time `db:"fetched_at"`
+	Body      []byte    `db:"body"`
+}
+
+func insertRawPayload(ctx context.Context, tx *sqlx.Tx, raw *RawPayload) error {
+	if raw == nil || len(raw.Body) == 0 {
+		return nil
+	}
+
+	body, err := compress(raw.Body)
+	if err != nil {
+		return err
+	}
+
+	sql := `INSERT INTO raw_payloads
+			(fetch_id, source, body)
+			VALUES
+			($1, $2, $3)`
+	_, err = tx.ExecContext(ctx, sql, raw.FetchID, raw.Source, body)
+	return err
+}
+
+func readRawPayload(ctx context.Context, db *sqlx.DB, fetchID string) (*RawPayload, error) {
+	sql := `SELECT fetch_id, source, fetched_at, body
+			FROM raw_payloads
+			WHERE fetch_id = $1`
+
+	var raw RawPayload
+	if err := db.GetContext(ctx, &raw, sql, fetchID); err != nil {
+		return nil, err
+	}
+
+	body, err := decompress(raw.Body)
+	if err != nil {
+		return nil, err
+	}
+	raw.Body = body
+
+	return &raw, nil
+}
+
+func compress(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(body); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(body []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	return io.ReadAll(gz)
+}
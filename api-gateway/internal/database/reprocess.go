@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// ListRawPayloads returns raw payloads (without body) of the snapshots
+// between from and to. The time is last_update for Indego and dt for
+// Open Weather. Empty source means all sources
+func (d *dbase) ListRawPayloads(
+	ctx context.Context,
+	source string,
+	from time.Time,
+	to time.Time,
+) ([]*RawPayload, error) {
+	sql := `SELECT r.fetch_id, r.source, r.fetched_at
+			FROM raw_payloads r
+			LEFT OUTER JOIN rideindego_master m ON m.fetch_id = r.fetch_id
+			LEFT OUTER JOIN openweather_master w ON w.fetch_id = r.fetch_id
+			WHERE COALESCE(m.last_update, to_timestamp(w.dt)) BETWEEN $1 AND $2
+			AND ($3 = '' OR r.source = $3)
+			ORDER BY COALESCE(m.last_update, to_timestamp(w.dt)) ASC`
+
+	var raws []*RawPayload
+	err := d.db.SelectContext(ctx, &raws, sql, from, to, source)
+	if err != nil {
+		handleError("ListRawPayloads", err)
+	}
+	return raws, err
+}
+
+// ReadRideIndego returns the stored rows of one snapshot
+func (d *dbase) ReadRideIndego(ctx context.Context, fetchID string) (result ParamStoreRideIndego, err error) {
+	findme := readRideIndego{db: d.db, ctx: ctx}
+
+	result.Master = &RideIndegoMaster{}
+	if err = findme.readMasterByID(fetchID, result.Master); err != nil {
+		handleError("readMasterByID", err)
+		return
+	}
+
+	if result.Features, err = findme.readFeatures(fetchID, -1); err != nil {
+		handleError("readFeatures", err)
+		return
+	}
+
+	if result.Properties, _, err = findme.readProperties(fetchID, -1, PropertiesFilter{}); err != nil {
+		handleError("readProperties", err)
+		return
+	}
+
+	bikes, err := findme.readPropBikes(fetchID, -1)
+	if err != nil {
+		handleError("readPropertiesBikes", err)
+		return
+	}
+	for _, bike := range bikes {
+		result.PropertiesBikes = append(result.PropertiesBikes, bike...)
+	}
+
+	return
+}
+
+// ReplaceRideIndego replace derived rows of an existing snapshot in one
+// transaction. Raw payload and the link to weather are kept
+func (d *dbase) ReplaceRideIndego(ctx context.Context, pInput ParamStoreRideIndego) (err error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	storeData := storeRideIndeGo{tx: tx, ctx: ctx}
+	if err = storeData.deleteDerived(pInput.Master.FetchID); err != nil {
+		handleError("deleteDerived", err)
+		return
+	}
+	if err = storeData.updateMaster(pInput.Master); err != nil {
+		handleError("updateMaster", err)
+		return
+	}
+	if err = storeData.insertFeatures(pInput.Features); err != nil {
+		handleError("insertFeatures", err)
+		return
+	}
+	if err = storeData.insertProperties(pInput.Properties); err != nil {
+		handleError("insertProperties", err)
+		return
+	}
+	if err = storeData.insertPropertiesBike(pInput.PropertiesBikes); err != nil {
+		handleError("insertPropertiesBike", err)
+		return
+	}
+
+	return tx.Commit()
+}
+
+// ReadOpenWeather returns the stored rows of one observation
+func (d *dbase) ReadOpenWeather(ctx context.Context, fetchID string) (result ParamStoreOpenWeather, err error) {
+	findme := readOpenWeather{db: d.db, ctx: ctx}
+
+	if result.Master, err = findme.readMasterByID(fetchID); err != nil {
+		handleError("readMasterByID", err)
+		return
+	}
+
+	if result.Details, err = findme.readDetail(fetchID); err != nil {
+		handleError("readDetail", err)
+	}
+
+	return
+}
+
+// ReplaceOpenWeather replace derived rows of an existing observation in one
+// transaction. Raw payload and the link to Indego snapshots are kept
+func (d *dbase) ReplaceOpenWeather(ctx context.Context, pInput ParamStoreOpenWeather) (err error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	storeData := storeWeather{tx: tx, ctx: ctx}
+	if err = storeData.deleteDetail(pInput.Master.FetchID); err != nil {
+		handleError("deleteDetail", err)
+		return
+	}
+	if err = storeData.updateMaster(pInput.Master); err != nil {
+		handleError("updateMaster", err)
+		return
+	}
+	if err = storeData.insertDetail(pInput.Details); err != nil {
+		handleError("insertDetail", err)
+		return
+	}
+
+	return tx.Commit()
+}
@@ -2,6 +2,9 @@ package database
 
 import (
 	"context"
+	dbsql "database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -13,13 +16,17 @@ type ParamStoreRideIndego struct {
 	Features        []*RideIndegoFeatures
 	Properties      []*RideIndegoProperties
 	PropertiesBikes []*RideIndegoBikes
+	Raw             *RawPayload
 }
 
+// Properties are in the order of the filter, Total is the number of
+// properties matching the filter before pagination
 type SearchResRideIndego struct {
 	Master          *RideIndegoMaster
 	Features        []*RideIndegoFeatures
-	Properties      map[int]*RideIndegoProperties
+	Properties      []*RideIndegoProperties
 	PropertiesBikes map[int][]*RideIndegoBikes
+	Total           int
 }
 
 // Structure table rideindego_properties_bikes
@@ -109,38 +116,49 @@ func withFeatureID(featureID int) bool {
 	return featureID != -1
 }
 
-func (r *readRideIndego) readMaster(at time.Time, kioskID int, v interface{}) error {
+func (r *readRideIndego) readMaster(q SnapshotQuery, kioskID int, v interface{}) error {
 	var (
 		sql  string
 		args []interface{}
 	)
 
-	// time parameter is mandatory
-	args = append(args, at)
+	// time range is mandatory
+	from, to := q.bounds()
+	args = append(args, from, to)
 
 	if withKioskID(kioskID) {
 		// find based on kioskID
 		sql = `SELECT m.fetch_id, m.type_collection, m.last_update, p.feat_id
 			   FROM rideindego_master m
 			   LEFT OUTER JOIN rideindego_properties p on p.fetch_id=m.fetch_id 
-			   WHERE m.last_update >= $1 and p.kiosk_id = $2
-			   ORDER by m.last_update ASC
-			   LIMIT 1`
+			   WHERE m.last_update BETWEEN $1 AND $2 and p.kiosk_id = $3`
 		args = append(args, kioskID)
 
 	} else {
 		// find only based on time
-		sql = `SELECT fetch_id, type_collection, last_update 
-			   FROM rideindego_master
-			   WHERE last_update >= $1
-			   ORDER by last_update ASC
-			   LIMIT 1`
+		sql = `SELECT m.fetch_id, m.type_collection, m.last_update 
+			   FROM rideindego_master m
+			   WHERE m.last_update BETWEEN $1 AND $2`
+	}
+
+	distance := fmt.Sprintf("abs(extract(epoch FROM m.last_update - $%d::timestamptz))", len(args)+1)
+	if q.Mode == ModeNearest {
+		args = append(args, q.At)
 	}
+	sql += " ORDER BY " + q.orderBy("m.last_update", distance) + " LIMIT 1"
 
 	err := r.db.GetContext(r.ctx, v, sql, args...)
 	return err
 }
 
+func (r *readRideIndego) readMasterByID(fetchID string, master *RideIndegoMaster) error {
+	sql := `SELECT fetch_id, type_collection, last_update 
+			FROM rideindego_master
+			WHERE fetch_id = $1`
+
+	return r.db.GetContext(r.ctx, master, sql, fetchID)
+}
+
 func (r *readRideIndego) readFeatures(fetchID string, featureID int) ([]*RideIndegoFeatures, error) {
 	var args []interface{}
 
@@ -161,7 +179,13 @@ func (r *readRideIndego) readFeatures(fetchID string, featureID int) ([]*RideInd
 	return features, err
 }
 
-func (r *readRideIndego) readProperties(fetchID string, featureID int) (map[int]*RideIndegoProperties, error) {
+// readProperties returns the properties matching the filter in the order
+// of the filter, and the number of matching properties before pagination
+func (r *readRideIndego) readProperties(
+	fetchID string,
+	featureID int,
+	filter PropertiesFilter,
+) ([]*RideIndegoProperties, int, error) {
 	var args []interface{}
 
 	// fetchID is mandatory
@@ -175,7 +199,7 @@ func (r *readRideIndego) readProperties(fetchID string, featureID int) (map[int]
 			address_zip_code, bikes_available, docks_available, trikes_available, 
 			kiosk_public_status, smart_bikes_available, reward_bikes_available, 
 			reward_docks_available, classic_bikes_available, kiosk_connection_status, 
-			electric_bikes_available
+			electric_bikes_available, count(*) OVER() AS total
 			FROM rideindego_properties
 			WHERE fetch_id=$1`
 
@@ -184,24 +208,34 @@ func (r *readRideIndego) readProperties(fetchID string, featureID int) (map[int]
 		args = append(args, featureID)
 	}
 
+	sql += filter.where(&args)
+	sql += filter.orderBy(&args)
+
 	rows, err := r.db.QueryxContext(r.ctx, sql, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	properties := make(map[int]*RideIndegoProperties, 0)
+	var (
+		properties []*RideIndegoProperties
+		total      int
+	)
 	for rows.Next() {
-		prop := RideIndegoProperties{}
+		prop := struct {
+			RideIndegoProperties
+			Total int `db:"total"`
+		}{}
 		err := rows.StructScan(&prop)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
-		properties[prop.FeatureID] = &prop
+		properties = append(properties, &prop.RideIndegoProperties)
+		total = prop.Total
 	}
 
-	return properties, nil
+	return properties, total, rows.Err()
 }
 
 func (r *readRideIndego) readPropBikes(fetchID string, featureID int) (map[int][]*RideIndegoBikes, error) {
@@ -218,6 +252,7 @@ func (r *readRideIndego) readPropBikes(fetchID string, featureID int) (map[int][
 		sql += " AND feat_id=$2"
 		args = append(args, featureID)
 	}
+	sql += " ORDER BY feat_id, idx"
 
 	rows, err := r.db.QueryxContext(r.ctx, sql, args...)
 	if err != nil {
@@ -245,6 +280,19 @@ type storeRideIndeGo struct {
 	ctx context.Context
 }
 
+// findMaster returns fetch_id of the snapshot with the given last_update,
+// or empty string when not found
+func (s *storeRideIndeGo) findMaster(lastUpdate time.Time) (string, error) {
+	sql := `SELECT fetch_id FROM rideindego_master WHERE last_update = $1`
+
+	var fetchID string
+	err := s.tx.GetContext(s.ctx, &fetchID, sql, lastUpdate)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return "", nil
+	}
+	return fetchID, err
+}
+
 func (s *storeRideIndeGo) insertMaster(master *RideIndegoMaster) error {
 	sql := `INSERT INTO rideindego_master 
 			(fetch_id, type_collection, last_update)
@@ -255,7 +303,35 @@ func (s *storeRideIndeGo) insertMaster(master *RideIndegoMaster) error {
 	return err
 }
 
+func (s *storeRideIndeGo) updateMaster(master *RideIndegoMaster) error {
+	sql := `UPDATE rideindego_master 
+			SET type_collection = :type_collection, last_update = :last_update
+			WHERE fetch_id = :fetch_id`
+
+	_, err := s.tx.NamedExecContext(s.ctx, sql, master)
+	return err
+}
+
+// deleteDerived delete features, properties and bikes of a snapshot
+func (s *storeRideIndeGo) deleteDerived(fetchID string) error {
+	for _, table := range []string{
+		"rideindego_properties_bikes",
+		"rideindego_properties",
+		"rideindego_features",
+	} {
+		sql := "DELETE FROM " + table + " WHERE fetch_id = $1"
+		if _, err := s.tx.ExecContext(s.ctx, sql, fetchID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *storeRideIndeGo) insertFeatures(features []*RideIndegoFeatures) error {
+	if len(features) == 0 {
+		return nil
+	}
+
 	sql := `INSERT INTO rideindego_features
 			(fetch_id, feat_id, ftype, geo_type, geo_coordinate)
 			VALUES
@@ -265,6 +341,10 @@ func (s *storeRideIndeGo) insertFeatures(features []*RideIndegoFeatures) error {
 }
 
 func (s *storeRideIndeGo) insertProperties(properties []*RideIndegoProperties) error {
+	if len(properties) == 0 {
+		return nil
+	}
+
 	sql := `INSERT INTO rideindego_properties
 			(fetch_id, feat_id, id, "name", notes, kiosk_id, event_end, latitude, 
 			 open_time, time_zone, close_time, is_virtual, kiosk_type, longitude, 
@@ -289,6 +369,10 @@ func (s *storeRideIndeGo) insertProperties(properties []*RideIndegoProperties) e
 }
 
 func (s *storeRideIndeGo) insertPropertiesBike(propBikes []*RideIndegoBikes) error {
+	if len(propBikes) == 0 {
+		return nil
+	}
+
 	sql := `INSERT INTO rideindego_properties_bikes
 			(fetch_id, feat_id, id, battery, dock_number, is_electric, is_available)
 			VALUES
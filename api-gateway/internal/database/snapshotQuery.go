@@ -0,0 +1,65 @@
+package database
+
+import (
+	"time"
+)
+
+// Snapshot selection relative to the requested time
+const (
+	ModeAfter   = "after"
+	ModeBefore  = "before"
+	ModeNearest = "nearest"
+)
+
+var (
+	minSnapshotTime = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxSnapshotTime = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// SnapshotQuery select one snapshot relative to At. Empty Mode is
+// ModeAfter for stations. MaxAge is the largest distance allowed between
+// At and the snapshot, zero means no limit
+type SnapshotQuery struct {
+	At     time.Time
+	Mode   string
+	MaxAge time.Duration
+}
+
+func ValidSnapshotMode(mode string) bool {
+	switch mode {
+	case "", ModeAfter, ModeBefore, ModeNearest:
+		return true
+	}
+	return false
+}
+
+// bounds returns the range of snapshot time allowed by mode and max age
+func (q SnapshotQuery) bounds() (time.Time, time.Time) {
+	from, to := minSnapshotTime, maxSnapshotTime
+	if q.MaxAge > 0 {
+		from, to = q.At.Add(-q.MaxAge), q.At.Add(q.MaxAge)
+	}
+
+	switch q.Mode {
+	case ModeBefore:
+		to = q.At
+	case ModeNearest:
+	default:
+		from = q.At
+	}
+
+	return from, to
+}
+
+// orderBy returns ORDER BY of column so the selected snapshot come first.
+// distance is the expression of distance between column and At
+func (q SnapshotQuery) orderBy(column string, distance string) string {
+	switch q.Mode {
+	case ModeBefore:
+		return column + " DESC"
+	case ModeNearest:
+		return distance + ", " + column + " ASC"
+	default:
+		return column + " ASC"
+	}
+}
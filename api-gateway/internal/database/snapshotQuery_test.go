@@ -0,0 +1,57 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnapshotQueryBounds(t *testing.T) {
+	at := time.Date(2024, 11, 8, 10, 0, 0, 0, time.UTC)
+
+	scenarios := []struct {
+		name         string
+		query        SnapshotQuery
+		expectedFrom time.Time
+		expectedTo   time.Time
+	}{
+		{
+			name:         "After without max age",
+			query:        SnapshotQuery{At: at},
+			expectedFrom: at,
+			expectedTo:   maxSnapshotTime,
+		},
+		{
+			name:         "After with max age",
+			query:        SnapshotQuery{At: at, Mode: ModeAfter, MaxAge: time.Hour},
+			expectedFrom: at,
+			expectedTo:   at.Add(time.Hour),
+		},
+		{
+			name:         "Before with max age",
+			query:        SnapshotQuery{At: at, Mode: ModeBefore, MaxAge: time.Hour},
+			expectedFrom: at.Add(-time.Hour),
+			expectedTo:   at,
+		},
+		{
+			name:         "Before without max age",
+			query:        SnapshotQuery{At: at, Mode: ModeBefore},
+			expectedFrom: minSnapshotTime,
+			expectedTo:   at,
+		},
+		{
+			name:         "Nearest with max age",
+			query:        SnapshotQuery{At: at, Mode: ModeNearest, MaxAge: 30 * time.Minute},
+			expectedFrom: at.Add(-30 * time.Minute),
+			expectedTo:   at.Add(30 * time.Minute),
+		},
+	}
+
+	for _, ts := range scenarios {
+		t.Run(ts.name, func(t *testing.T) {
+			from, to := ts.query.bounds()
+			if !from.Equal(ts.expectedFrom) || !to.Equal(ts.expectedTo) {
+				t.Errorf("Expected %v - %v but got %v - %v", ts.expectedFrom, ts.expectedTo, from, to)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	AnomalyStuck       = "stuck"
+	AnomalyOffline     = "offline"
+	AnomalyUnavailable = "unavailable"
+)
+
+// Structure table station_anomalies. An anomaly is open while ClosedAt is
+// nil, LastSeenAt is the last snapshot where the anomaly was detected
+type StationAnomaly struct {
+	AnomalyID  int        `db:"anomaly_id"`
+	KioskID    int        `db:"kiosk_id"`
+	Name       string     `db:"name"`
+	Kind       string     `db:"kind"`
+	Detail     string     `db:"detail"`
+	OpenedAt   time.Time  `db:"opened_at"`
+	LastSeenAt time.Time  `db:"last_seen_at"`
+	ClosedAt   *time.Time `db:"closed_at"`
+}
+
+// AnomalyQuery select the anomalies, all of them when the fields are empty.
+// Open true returns the open anomalies only, false the closed ones only
+type AnomalyQuery struct {
+	Open    *bool
+	Kind    string
+	KioskID *int
+	Limit   int
+}
+
+// AnomalySample is one station on one snapshot, the input of the detector
+type AnomalySample struct {
+	KioskID          int       `db:"kiosk_id"`
+	At               time.Time `db:"at"`
+	Name             string    `db:"name"`
+	BikesAvailable   int       `db:"bikes_available"`
+	DocksAvailable   int       `db:"docks_available"`
+	KioskStatus      string    `db:"kiosk_status"`
+	PublicStatus     string    `db:"kiosk_public_status"`
+	ConnectionStatus string    `db:"kiosk_connection_status"`
+}
+
+type stationAnomalies struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+// readSamples returns the stations of the last snapshots taken up to at,
+// ordered by station then newest first
+func (s *stationAnomalies) readSamples(at time.Time, snapshots int) ([]*AnomalySample, error) {
+	sql := `WITH snapshots AS (
+				SELECT fetch_id, last_update
+				FROM rideindego_master
+				WHERE last_update <= $1
+				ORDER BY last_update DESC
+				LIMIT $2
+			)
+			SELECT p.kiosk_id, s.last_update AS at, coalesce(p.name, '') AS name,
+			coalesce(p.bikes_available, 0) AS bikes_available,
+			coalesce(p.docks_available, 0) AS docks_available,
+			coalesce(p.kiosk_status, '') AS kiosk_status,
+			coalesce(p.kiosk_public_status, '') AS kiosk_public_status,
+			coalesce(p.kiosk_connection_status, '') AS kiosk_connection_status
+			FROM snapshots s
+			INNER JOIN rideindego_properties p ON p.fetch_id = s.fetch_id
+			WHERE p.kiosk_id IS NOT NULL
+			ORDER BY p.kiosk_id ASC, s.last_update DESC`
+
+	var rows []*AnomalySample
+	err := s.db.SelectContext(s.ctx, &rows, sql, at, snapshots)
+	return rows, err
+}
+
+// read returns the anomalies of q, the last opened first
+func (s *stationAnomalies) read(q AnomalyQuery) ([]*StationAnomaly, error) {
+	var (
+		filter string
+		args   []interface{}
+	)
+	if q.Open != nil {
+		if *q.Open {
+			filter += " AND closed_at IS NULL"
+		} else {
+			filter += " AND closed_at IS NOT NULL"
+		}
+	}
+	if len(q.Kind) > 0 {
+		args = append(args, q.Kind)
+		filter += fmt.Sprintf(" AND kind = $%d", len(args))
+	}
+	if q.KioskID != nil {
+		args = append(args, *q.KioskID)
+		filter += fmt.Sprintf(" AND kiosk_id = $%d", len(args))
+	}
+	args = append(args, q.Limit)
+
+	sql := `SELECT anomaly_id, kiosk_id, name, kind, detail, opened_at, last_seen_at, closed_at
+			FROM station_anomalies
+			WHERE true` + filter + `
+			ORDER BY opened_at DESC, anomaly_id DESC
+			LIMIT $` + fmt.Sprint(len(args))
+
+	var rows []*StationAnomaly
+	err := s.db.SelectContext(s.ctx, &rows, sql, args...)
+	return rows, err
+}
+
+type saveStationAnomalies struct {
+	tx  *sqlx.Tx
+	ctx context.Context
+}
+
+func (s *saveStationAnomalies) insert(anomaly *StationAnomaly) error {
+	sql := `INSERT INTO station_anomalies
+			(kiosk_id, name, kind, detail, opened_at, last_seen_at, closed_at)
+			VALUES
+			(:kiosk_id, :name, :kind, :detail, :opened_at, :last_seen_at, :closed_at)`
+	_, err := s.tx.NamedExecContext(s.ctx, sql, anomaly)
+	return err
+}
+
+func (s *saveStationAnomalies) update(anomaly *StationAnomaly) error {
+	sql := `UPDATE station_anomalies SET
+			detail = :detail, last_seen_at = :last_seen_at, closed_at = :closed_at
+			WHERE anomaly_id = :anomaly_id`
+	_, err := s.tx.NamedExecContext(s.ctx, sql, anomaly)
+	return err
+}
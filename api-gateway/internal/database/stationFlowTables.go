@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// FlowMaxGap is the largest time between two snapshots of a station to
+// infer its flow, a longer gap is ignored
+const FlowMaxGap = 3 * time.Hour
+
+// FlowQuery select the flows of the hours between From and To, of one
+// station when KioskID is not nil
+type FlowQuery struct {
+	KioskID *int
+	From    time.Time
+	To      time.Time
+}
+
+// FlowInterval is the bikes which left (Departures) and returned
+// (Arrivals) in one hour, inferred from the change of bikes between
+// consecutive snapshots
+type FlowInterval struct {
+	Bucket     time.Time `db:"bucket"`
+	Departures int       `db:"departures"`
+	Arrivals   int       `db:"arrivals"`
+}
+
+type StationFlow struct {
+	KioskID    int `db:"kiosk_id"`
+	Departures int `db:"departures"`
+	Arrivals   int `db:"arrivals"`
+}
+
+// FlowHour is the flows of all stations in one hour of the day, in
+// TimeZone
+type FlowHour struct {
+	Hour       int `db:"hour"`
+	Departures int `db:"departures"`
+	Arrivals   int `db:"arrivals"`
+}
+
+type SearchResFlows struct {
+	Intervals    []*FlowInterval
+	Stations     []*StationFlow
+	BusiestHours []*FlowHour
+}
+
+type stationFlows struct {
+	tx  *sqlx.Tx
+	ctx context.Context
+}
+
+// refresh rebuild the flows of the hours in [from, to). A flow is put in
+// the hour of the later snapshot of the pair
+func (s *stationFlows) refresh(from time.Time, to time.Time) error {
+	sql := `DELETE FROM station_flows WHERE bucket >= $1 AND bucket < $2`
+	if _, err := s.tx.ExecContext(s.ctx, sql, from, to); err != nil {
+		return err
+	}
+
+	sql = `WITH samples AS (
+			   SELECT p.kiosk_id, m.last_update, p.bikes_available,
+			   lag(p.bikes_available) OVER w AS prev_bikes,
+			   lag(m.last_update) OVER w AS prev_update
+			   FROM rideindego_master m
+			   INNER JOIN rideindego_properties p ON p.fetch_id = m.fetch_id
+			   WHERE m.last_update >= $3 AND m.last_update < $2
+			   AND p.kiosk_id IS NOT NULL AND p.bikes_available IS NOT NULL
+			   WINDOW w AS (PARTITION BY p.kiosk_id ORDER BY m.last_update)
+		   )
+		   INSERT INTO station_flows (kiosk_id, bucket, samples, departures, arrivals)
+		   SELECT kiosk_id,
+		   date_trunc('hour', last_update AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+		   count(*),
+		   sum(greatest(prev_bikes - bikes_available, 0)),
+		   sum(greatest(bikes_available - prev_bikes, 0))
+		   FROM samples
+		   WHERE last_update >= $1 AND prev_bikes IS NOT NULL
+		   AND extract(epoch FROM last_update - prev_update) <= $4
+		   GROUP BY 1, 2`
+	_, err := s.tx.ExecContext(s.ctx, sql, from, to, from.Add(-FlowMaxGap), FlowMaxGap.Seconds())
+	return err
+}
+
+type readStationFlows struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+func (r *readStationFlows) readIntervals(q FlowQuery) ([]*FlowInterval, error) {
+	sql := `SELECT bucket, sum(departures) AS departures, sum(arrivals) AS arrivals
+			FROM station_flows
+			WHERE bucket BETWEEN $1 AND $2
+			AND ($3::integer IS NULL OR kiosk_id = $3)
+			GROUP BY bucket
+			ORDER BY bucket ASC`
+
+	var rows []*FlowInterval
+	err := r.db.SelectContext(r.ctx, &rows, sql, q.From, q.To, q.KioskID)
+	return rows, err
+}
+
+// readStations returns the flows of each station, the busiest first
+func (r *readStationFlows) readStations(q FlowQuery) ([]*StationFlow, error) {
+	sql := `SELECT kiosk_id, sum(departures) AS departures, sum(arrivals) AS arrivals
+			FROM station_flows
+			WHERE bucket BETWEEN $1 AND $2
+			AND ($3::integer IS NULL OR kiosk_id = $3)
+			GROUP BY kiosk_id
+			ORDER BY sum(departures) + sum(arrivals) DESC, kiosk_id ASC`
+
+	var rows []*StationFlow
+	err := r.db.SelectContext(r.ctx, &rows, sql, q.From, q.To, q.KioskID)
+	return rows, err
+}
+
+// readBusiestHours returns the flows of all stations by hour of the day in
+// TimeZone, at most limit hours, the busiest first
+func (r *readStationFlows) readBusiestHours(from time.Time, to time.Time, limit int) ([]*FlowHour, error) {
+	sql := `SELECT extract(hour FROM bucket AT TIME ZONE $4)::integer AS hour,
+			sum(departures) AS departures, sum(arrivals) AS arrivals
+			FROM station_flows
+			WHERE bucket BETWEEN $1 AND $2
+			GROUP BY 1
+			ORDER BY sum(departures) + sum(arrivals) DESC, 1 ASC
+			LIMIT $3`
+
+	var rows []*FlowHour
+	err := r.db.SelectContext(r.ctx, &rows, sql, from, to, limit, TimeZone)
+	return rows, err
+}
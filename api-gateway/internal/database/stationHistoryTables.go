@@ -0,0 +1,67 @@
+package database
+
+import (
+	"time"
+)
+
+// StationHistory is the availability of one station on one snapshot, or
+// the average of the snapshots in one bucket when downsampled. KioskStatus
+// is the status on the last snapshot of the bucket
+type StationHistory struct {
+	At                     time.Time `db:"at"`
+	BikesAvailable         float64   `db:"bikes_available"`
+	DocksAvailable         float64   `db:"docks_available"`
+	ClassicBikesAvailable  float64   `db:"classic_bikes_available"`
+	ElectricBikesAvailable float64   `db:"electric_bikes_available"`
+	SmartBikesAvailable    float64   `db:"smart_bikes_available"`
+	KioskStatus            string    `db:"kiosk_status"`
+	Samples                int       `db:"samples"`
+}
+
+// readHistory returns at most limit points of the station between from and
+// to, oldest first. Interval zero returns every snapshot, otherwise the
+// snapshots are averaged in buckets of interval aligned to unix epoch
+func (r *readRideIndego) readHistory(
+	kioskID int,
+	from time.Time,
+	to time.Time,
+	interval time.Duration,
+	limit int,
+) ([]*StationHistory, error) {
+	var (
+		sql  string
+		args = []interface{}{kioskID, from, to, limit}
+	)
+
+	if interval <= 0 {
+		sql = `SELECT m.last_update AS at, 
+			   p.bikes_available, p.docks_available, p.classic_bikes_available, 
+			   p.electric_bikes_available, p.smart_bikes_available, 
+			   p.kiosk_status, 1 AS samples
+			   FROM rideindego_master m
+			   INNER JOIN rideindego_properties p ON p.fetch_id = m.fetch_id
+			   WHERE p.kiosk_id = $1 AND m.last_update BETWEEN $2 AND $3
+			   ORDER BY m.last_update ASC
+			   LIMIT $4`
+	} else {
+		sql = `SELECT to_timestamp(floor(extract(epoch FROM m.last_update) / $5::float8) * $5::float8) AS at,
+			   round(avg(p.bikes_available), 2)::float8 AS bikes_available,
+			   round(avg(p.docks_available), 2)::float8 AS docks_available,
+			   round(avg(p.classic_bikes_available), 2)::float8 AS classic_bikes_available,
+			   round(avg(p.electric_bikes_available), 2)::float8 AS electric_bikes_available,
+			   round(avg(p.smart_bikes_available), 2)::float8 AS smart_bikes_available,
+			   (array_agg(p.kiosk_status ORDER BY m.last_update DESC))[1] AS kiosk_status,
+			   count(*) AS samples
+			   FROM rideindego_master m
+			   INNER JOIN rideindego_properties p ON p.fetch_id = m.fetch_id
+			   WHERE p.kiosk_id = $1 AND m.last_update BETWEEN $2 AND $3
+			   GROUP BY 1
+			   ORDER BY 1 ASC
+			   LIMIT $4`
+		args = append(args, int64(interval.Seconds()))
+	}
+
+	var points []*StationHistory
+	err := r.db.SelectContext(r.ctx, &points, sql, args...)
+	return points, err
+}
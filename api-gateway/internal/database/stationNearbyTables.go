@@ -0,0 +1,60 @@
+package database
+
+import (
+	"strconv"
+)
+
+// NearbyQuery find stations within Radius meters of the point Lat, Lon.
+// Limit zero means no limit
+type NearbyQuery struct {
+	Lat    float64
+	Lon    float64
+	Radius float64
+	Limit  int
+	Offset int
+	Filter PropertiesFilter
+}
+
+// NearbyProperties is a station with its distance in meters to the point
+type NearbyProperties struct {
+	RideIndegoProperties
+	Distance float64 `db:"distance"`
+}
+
+type SearchResNearby struct {
+	Master          *RideIndegoMaster
+	Properties      []*NearbyProperties
+	PropertiesBikes map[int][]*RideIndegoBikes
+}
+
+// readNearby returns the stations of the snapshot within the radius, the
+// nearest first. Only the conditions of the filter are used
+func (r *readRideIndego) readNearby(fetchID string, q NearbyQuery) ([]*NearbyProperties, error) {
+	args := []interface{}{fetchID, q.Lon, q.Lat, q.Radius}
+
+	sql := `SELECT fetch_id, feat_id, id, "name", notes, kiosk_id, event_end, 
+			latitude, open_time, time_zone, close_time, is_virtual, kiosk_type, 
+			longitude, event_start, public_text, total_docks, address_city, 
+			ST_AsText(coordinates) AS coordinates, 
+			kiosk_status, address_state, is_event_based, address_street, 
+			address_zip_code, bikes_available, docks_available, trikes_available, 
+			kiosk_public_status, smart_bikes_available, reward_bikes_available, 
+			reward_docks_available, classic_bikes_available, kiosk_connection_status, 
+			electric_bikes_available,
+			ST_Distance(coordinates::geography, ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography) AS distance
+			FROM rideindego_properties
+			WHERE fetch_id=$1
+			AND ST_DWithin(coordinates::geography, ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography, $4)`
+
+	sql += q.Filter.where(&args)
+	sql += ` ORDER BY coordinates::geography <-> ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography, feat_id`
+
+	if q.Limit > 0 {
+		args = append(args, q.Limit, q.Offset)
+		sql += " LIMIT $" + strconv.Itoa(len(args)-1) + " OFFSET $" + strconv.Itoa(len(args))
+	}
+
+	var properties []*NearbyProperties
+	err := r.db.SelectContext(r.ctx, &properties, sql, args...)
+	return properties, err
+}
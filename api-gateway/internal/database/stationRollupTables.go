@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	RollupHour = "hour"
+	RollupDay  = "day"
+)
+
+// rollupTables is the table of each granularity, also the whitelist of
+// the table name put in the queries
+var rollupTables = map[string]string{
+	RollupHour: "station_hourly",
+	RollupDay:  "station_daily",
+}
+
+func ValidRollupGranularity(granularity string) bool {
+	_, ok := rollupTables[granularity]
+	return ok
+}
+
+// RollupBucket returns the bucket of t for the granularity. Hours are
+// aligned to UTC, days start at midnight in TimeZone
+func RollupBucket(granularity string, t time.Time) time.Time {
+	if granularity == RollupDay {
+		t = t.In(localZone)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, localZone)
+	}
+	return t.UTC().Truncate(time.Hour)
+}
+
+// Structure table station_hourly and station_daily. PctEmpty and PctFull
+// are the percent of snapshots without bike and without free dock
+type StationRollup struct {
+	KioskID  int       `db:"kiosk_id"`
+	Bucket   time.Time `db:"bucket"`
+	Samples  int       `db:"samples"`
+	BikesMin int       `db:"bikes_min"`
+	BikesMax int       `db:"bikes_max"`
+	BikesAvg float64   `db:"bikes_avg"`
+	DocksMin int       `db:"docks_min"`
+	DocksMax int       `db:"docks_max"`
+	DocksAvg float64   `db:"docks_avg"`
+	PctEmpty float64   `db:"pct_empty"`
+	PctFull  float64   `db:"pct_full"`
+}
+
+// SystemRollup is the rollup of all stations in one bucket. BikesAvg and
+// DocksAvg are the sum of the station averages, PctEmpty and PctFull the
+// average over the stations
+type SystemRollup struct {
+	Bucket   time.Time `db:"bucket"`
+	Stations int       `db:"stations"`
+	Samples  int       `db:"samples"`
+	BikesAvg float64   `db:"bikes_avg"`
+	DocksAvg float64   `db:"docks_avg"`
+	PctEmpty float64   `db:"pct_empty"`
+	PctFull  float64   `db:"pct_full"`
+}
+
+type stationRollups struct {
+	tx  *sqlx.Tx
+	ctx context.Context
+}
+
+// refreshHourly rebuild the hourly rows of the hours in [from, to) from
+// the stored snapshots
+func (s *stationRollups) refreshHourly(from time.Time, to time.Time) error {
+	sql := `DELETE FROM station_hourly WHERE bucket >= $1 AND bucket < $2`
+	if _, err := s.tx.ExecContext(s.ctx, sql, from, to); err != nil {
+		return err
+	}
+
+	sql = `INSERT INTO station_hourly
+		   (kiosk_id, bucket, samples, bikes_min, bikes_max, bikes_avg,
+		   docks_min, docks_max, docks_avg, pct_empty, pct_full)
+		   SELECT p.kiosk_id,
+		   date_trunc('hour', m.last_update AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+		   count(*),
+		   min(p.bikes_available), max(p.bikes_available), avg(p.bikes_available),
+		   min(p.docks_available), max(p.docks_available), avg(p.docks_available),
+		   100.0 * count(*) FILTER (WHERE p.bikes_available = 0) / count(*),
+		   100.0 * count(*) FILTER (WHERE p.docks_available = 0) / count(*)
+		   FROM rideindego_master m
+		   INNER JOIN rideindego_properties p ON p.fetch_id = m.fetch_id
+		   WHERE m.last_update >= $1 AND m.last_update < $2
+		   AND p.kiosk_id IS NOT NULL
+		   AND p.bikes_available IS NOT NULL AND p.docks_available IS NOT NULL
+		   GROUP BY 1, 2`
+	_, err := s.tx.ExecContext(s.ctx, sql, from, to)
+	return err
+}
+
+// refreshDaily rebuild the daily rows of the days in [from, to) from the
+// hourly rows, the averages are weighted by samples. A day starts at
+// midnight in TimeZone, whose offsets are whole hours so each hourly row
+// falls in one day
+func (s *stationRollups) refreshDaily(from time.Time, to time.Time) error {
+	sql := `DELETE FROM station_daily WHERE bucket >= $1 AND bucket < $2`
+	if _, err := s.tx.ExecContext(s.ctx, sql, from, to); err != nil {
+		return err
+	}
+
+	sql = `INSERT INTO station_daily
+		   (kiosk_id, bucket, samples, bikes_min, bikes_max, bikes_avg,
+		   docks_min, docks_max, docks_avg, pct_empty, pct_full)
+		   SELECT kiosk_id,
+		   date_trunc('day', bucket AT TIME ZONE $3) AT TIME ZONE $3,
+		   sum(samples),
+		   min(bikes_min), max(bikes_max), sum(bikes_avg * samples) / sum(samples),
+		   min(docks_min), max(docks_max), sum(docks_avg * samples) / sum(samples),
+		   sum(pct_empty * samples) / sum(samples),
+		   sum(pct_full * samples) / sum(samples)
+		   FROM station_hourly
+		   WHERE bucket >= $1 AND bucket < $2
+		   GROUP BY 1, 2`
+	_, err := s.tx.ExecContext(s.ctx, sql, from, to, TimeZone)
+	return err
+}
+
+type readStationRollups struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+// readStation returns at most limit buckets of one station starting
+// between from and to, oldest first
+func (r *readStationRollups) readStation(
+	granularity string,
+	kioskID int,
+	from time.Time,
+	to time.Time,
+	limit int,
+) ([]*StationRollup, error) {
+	table, ok := rollupTables[granularity]
+	if !ok {
+		return nil, fmt.Errorf("invalid granularity: %s", granularity)
+	}
+
+	sql := `SELECT kiosk_id, bucket, samples, bikes_min, bikes_max,
+			round(bikes_avg::numeric, 2)::float8 AS bikes_avg,
+			docks_min, docks_max,
+			round(docks_avg::numeric, 2)::float8 AS docks_avg,
+			round(pct_empty::numeric, 2)::float8 AS pct_empty,
+			round(pct_full::numeric, 2)::float8 AS pct_full
+			FROM ` + table + `
+			WHERE kiosk_id = $1 AND bucket BETWEEN $2 AND $3
+			ORDER BY bucket ASC
+			LIMIT $4`
+
+	var rows []*StationRollup
+	err := r.db.SelectContext(r.ctx, &rows, sql, kioskID, from, to, limit)
+	return rows, err
+}
+
+// readSystem returns at most limit buckets of all stations starting
+// between from and to, oldest first
+func (r *readStationRollups) readSystem(
+	granularity string,
+	from time.Time,
+	to time.Time,
+	limit int,
+) ([]*SystemRollup, error) {
+	table, ok := rollupTables[granularity]
+	if !ok {
+		return nil, fmt.Errorf("invalid granularity: %s", granularity)
+	}
+
+	sql := `SELECT bucket, count(*) AS stations, sum(samples) AS samples,
+			round(sum(bikes_avg)::numeric, 2)::float8 AS bikes_avg,
+			round(sum(docks_avg)::numeric, 2)::float8 AS docks_avg,
+			round(avg(pct_empty)::numeric, 2)::float8 AS pct_empty,
+			round(avg(pct_full)::numeric, 2)::float8 AS pct_full
+			FROM ` + table + `
+			WHERE bucket BETWEEN $1 AND $2
+			GROUP BY bucket
+			ORDER BY bucket ASC
+			LIMIT $3`
+
+	var rows []*SystemRollup
+	err := r.db.SelectContext(r.ctx, &rows, sql, from, to, limit)
+	return rows, err
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRollupBucket(t *testing.T) {
+	at := time.Date(2024, 11, 8, 14, 37, 5, 0, time.FixedZone("EST", -5*3600))
+
+	if b := RollupBucket(RollupHour, at); !b.Equal(time.Date(2024, 11, 8, 19, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected hour bucket 2024-11-08T19:00:00Z but got %v", b)
+	}
+
+	// the day starts at midnight in TimeZone, not in the zone of at
+	if b := RollupBucket(RollupDay, at); !b.Equal(time.Date(2024, 11, 8, 5, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected day bucket 2024-11-08T05:00:00Z but got %v", b)
+	}
+
+	// still the day before in Philadelphia
+	at = time.Date(2024, 11, 8, 3, 0, 0, 0, time.UTC)
+	if b := RollupBucket(RollupDay, at); !b.Equal(time.Date(2024, 11, 7, 5, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected day bucket 2024-11-07T05:00:00Z but got %v", b)
+	}
+
+	// daylight saving time, midnight is 04:00 UTC
+	at = time.Date(2024, 7, 4, 12, 0, 0, 0, time.UTC)
+	if b := RollupBucket(RollupDay, at); !b.Equal(time.Date(2024, 7, 4, 4, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected day bucket 2024-07-04T04:00:00Z but got %v", b)
+	}
+}
+
+func TestValidRollupGranularity(t *testing.T) {
+	for _, granularity := range []string{RollupHour, RollupDay} {
+		if !ValidRollupGranularity(granularity) {
+			t.Errorf("Expected %s to be valid", granularity)
+		}
+	}
+
+	for _, granularity := range []string{"", "week", "station_hourly"} {
+		if ValidRollupGranularity(granularity) {
+			t.Errorf("Expected %s to be invalid", granularity)
+		}
+	}
+}
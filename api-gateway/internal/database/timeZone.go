@@ -0,0 +1,14 @@
+package database
+
+import (
+	"time"
+	// the runtime image has no zone database
+	_ "time/tzdata"
+)
+
+// TimeZone is the zone of the Indego stations, the local days and hours
+// of the stats and analytics are in this zone
+const TimeZone = "America/New_York"
+
+// localZone is TimeZone, always found since the zone database is embedded
+var localZone, _ = time.LoadLocation(TimeZone)
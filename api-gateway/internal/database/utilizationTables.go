@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	RankEmpty    = "empty"
+	RankFull     = "full"
+	RankTurnover = "turnover"
+)
+
+// utilizationRanks map the rank of utilization to the column, also the
+// whitelist of the column put in the query
+var utilizationRanks = map[string]string{
+	RankEmpty:    "pct_empty",
+	RankFull:     "pct_full",
+	RankTurnover: "turnover",
+}
+
+func ValidUtilizationRank(rankBy string) bool {
+	_, ok := utilizationRanks[rankBy]
+	return ok
+}
+
+// UtilizationQuery select the snapshots between From and To of the stations
+// in Zip and of KioskType, both optional. The Top stations by RankBy are
+// returned
+type UtilizationQuery struct {
+	From      time.Time
+	To        time.Time
+	Zip       string
+	KioskType *int
+	RankBy    string
+	Top       int
+}
+
+// StationUtilization is the utilization of one station. Turnover is the
+// average absolute change of bikes between consecutive snapshots, Total
+// the number of stations ranked
+type StationUtilization struct {
+	Rank      int     `db:"rank"`
+	KioskID   int     `db:"kiosk_id"`
+	Name      string  `db:"name"`
+	Zip       string  `db:"zip"`
+	KioskType int     `db:"kiosk_type"`
+	Samples   int     `db:"samples"`
+	PctEmpty  float64 `db:"pct_empty"`
+	PctFull   float64 `db:"pct_full"`
+	Turnover  float64 `db:"turnover"`
+	Total     int     `db:"total"`
+}
+
+type readUtilization struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+func (r *readUtilization) read(q UtilizationQuery) ([]*StationUtilization, error) {
+	column, ok := utilizationRanks[q.RankBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid rank: %s", q.RankBy)
+	}
+
+	var (
+		filter string
+		args   = []interface{}{q.From, q.To}
+	)
+	if len(q.Zip) > 0 {
+		args = append(args, q.Zip)
+		filter += fmt.Sprintf(" AND p.address_zip_code = $%d", len(args))
+	}
+	if q.KioskType != nil {
+		args = append(args, *q.KioskType)
+		filter += fmt.Sprintf(" AND p.kiosk_type = $%d", len(args))
+	}
+	args = append(args, q.Top)
+
+	sql := `WITH samples AS (
+				SELECT p.kiosk_id, p.name, p.address_zip_code, p.kiosk_type,
+				p.bikes_available, p.docks_available, m.last_update,
+				abs(p.bikes_available - lag(p.bikes_available)
+					OVER (PARTITION BY p.kiosk_id ORDER BY m.last_update)) AS bikes_change
+				FROM rideindego_master m
+				INNER JOIN rideindego_properties p ON p.fetch_id = m.fetch_id
+				WHERE m.last_update BETWEEN $1 AND $2
+				AND p.kiosk_id IS NOT NULL` + filter + `
+			), stations AS (
+				SELECT kiosk_id,
+				coalesce((array_agg(name ORDER BY last_update DESC))[1], '') AS name,
+				coalesce((array_agg(address_zip_code ORDER BY last_update DESC))[1], '') AS zip,
+				coalesce((array_agg(kiosk_type ORDER BY last_update DESC))[1], 0) AS kiosk_type,
+				count(*) AS samples,
+				round(100.0 * count(*) FILTER (WHERE bikes_available = 0) / count(*), 2)::float8 AS pct_empty,
+				round(100.0 * count(*) FILTER (WHERE docks_available = 0) / count(*), 2)::float8 AS pct_full,
+				round(coalesce(avg(bikes_change), 0), 2)::float8 AS turnover
+				FROM samples
+				GROUP BY kiosk_id
+			)
+			SELECT rank() OVER (ORDER BY ` + column + ` DESC) AS rank,
+			kiosk_id, name, zip, kiosk_type, samples, pct_empty, pct_full, turnover,
+			count(*) OVER () AS total
+			FROM stations
+			ORDER BY rank, kiosk_id
+			LIMIT $` + fmt.Sprint(len(args))
+
+	var rows []*StationUtilization
+	err := r.db.SelectContext(r.ctx, &rows, sql, args...)
+	return rows, err
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	WeatherTemperature = "temperature"
+	WeatherRain        = "rain"
+	WeatherWind        = "wind"
+	WeatherCondition   = "condition"
+)
+
+// WeatherImpactQuery select the snapshots between From and To, of one
+// station when KioskID is not nil
+type WeatherImpactQuery struct {
+	KioskID *int
+	From    time.Time
+	To      time.Time
+}
+
+// WeatherImpact is the availability of the snapshots in one band of one
+// weather dimension. BandOrder sort the bands of the same dimension
+type WeatherImpact struct {
+	Dimension string  `db:"dimension"`
+	Band      string  `db:"band"`
+	BandOrder int     `db:"band_order"`
+	Samples   int     `db:"samples"`
+	BikesAvg  float64 `db:"bikes_avg"`
+	Turnover  float64 `db:"turnover"`
+}
+
+// snapshotWeather join the snapshot m with its linked weather, or the
+// nearest observation within one hour when not linked. Columns of w are
+// null when there is no weather. The link is read by the primary key and
+// the fallback by the dt index, only when there is no link
+const snapshotWeather = `LEFT JOIN LATERAL (
+		SELECT o.fetch_id AS weather_id, o.main_temp, o.rain_one_hour, o.wind_speed,
+		(SELECT d.main FROM openweather_weather d
+			WHERE d.fetch_id = o.fetch_id ORDER BY d.idx LIMIT 1) AS condition
+		FROM openweather_master o
+		WHERE o.fetch_id = coalesce(m.weather_fetch_id, (
+			SELECT n.fetch_id FROM openweather_master n
+			WHERE n.dt BETWEEN extract(epoch FROM m.last_update) - 3600
+			AND extract(epoch FROM m.last_update) + 3600
+			ORDER BY abs(n.dt - extract(epoch FROM m.last_update))
+			LIMIT 1))
+	) w ON true`
+
+type readWeatherImpact struct {
+	db  *sqlx.DB
+	ctx context.Context
+}
+
+// read match every snapshot with its weather by snapshotWeather. Snapshots
+// without weather only count for the turnover of the next snapshot.
+// Temperature is in Kelvin upstream, the bands are 5 degree Celsius, rain
+// in mm/h and wind in m/s. Without temperature or wind the band is unknown
+func (r *readWeatherImpact) read(q WeatherImpactQuery) ([]*WeatherImpact, error) {
+	var (
+		filter string
+		args   = []interface{}{q.From, q.To}
+	)
+	if q.KioskID != nil {
+		args = append(args, *q.KioskID)
+		filter = " AND p.kiosk_id = $3"
+	}
+
+	sql := `WITH snapshots AS (
+				SELECT m.fetch_id, m.last_update, w.weather_id, w.main_temp,
+				w.rain_one_hour, w.wind_speed, w.condition
+				FROM rideindego_master m
+				` + snapshotWeather + `
+				WHERE m.last_update BETWEEN $1 AND $2
+			), samples AS (
+				SELECT s.weather_id, s.main_temp, s.rain_one_hour, s.wind_speed, s.condition,
+				p.bikes_available,
+				abs(p.bikes_available - lag(p.bikes_available)
+					OVER (PARTITION BY p.kiosk_id ORDER BY s.last_update)) AS bikes_change
+				FROM snapshots s
+				INNER JOIN rideindego_properties p ON p.fetch_id = s.fetch_id
+				WHERE p.kiosk_id IS NOT NULL` + filter + `
+			), bands AS (
+				SELECT bikes_available, bikes_change,
+				(floor((main_temp - 273.15) / 5) * 5)::int AS temp_band,
+				CASE WHEN coalesce(rain_one_hour, 0) = 0 THEN 0
+					WHEN rain_one_hour < 2.5 THEN 1
+					WHEN rain_one_hour < 7.6 THEN 2
+					ELSE 3 END AS rain_band,
+				CASE WHEN wind_speed IS NULL THEN NULL
+					WHEN wind_speed < 3.4 THEN 0
+					WHEN wind_speed < 8 THEN 1
+					WHEN wind_speed < 13.9 THEN 2
+					ELSE 3 END AS wind_band,
+				coalesce(condition, 'Unknown') AS condition
+				FROM samples
+				WHERE weather_id IS NOT NULL
+			)
+			SELECT
+			CASE WHEN GROUPING(temp_band) = 0 THEN 'temperature'
+				WHEN GROUPING(rain_band) = 0 THEN 'rain'
+				WHEN GROUPING(wind_band) = 0 THEN 'wind'
+				ELSE 'condition' END AS dimension,
+			CASE WHEN GROUPING(temp_band) = 0 THEN coalesce(temp_band || '..' || (temp_band + 5), 'unknown')
+				WHEN GROUPING(rain_band) = 0 THEN (ARRAY['none', 'light', 'moderate', 'heavy'])[rain_band + 1]
+				WHEN GROUPING(wind_band) = 0 THEN coalesce((ARRAY['light', 'moderate', 'strong', 'gale'])[wind_band + 1], 'unknown')
+				ELSE condition END AS band,
+			coalesce(temp_band, rain_band, wind_band, 0) AS band_order,
+			count(*) AS samples,
+			round(avg(bikes_available), 2)::float8 AS bikes_avg,
+			round(coalesce(avg(bikes_change), 0), 2)::float8 AS turnover
+			FROM bands
+			GROUP BY GROUPING SETS ((temp_band), (rain_band), (wind_band), (condition))
+			ORDER BY 1, 3, 2`
+
+	var rows []*WeatherImpact
+	err := r.db.SelectContext(r.ctx, &rows, sql, args...)
+	return rows, err
+}
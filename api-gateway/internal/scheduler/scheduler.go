@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Task is the unit of work executed by a Job. It returns the store status,
+// the upstream HTTP code and the error of the run.
+type Task func() (string, int, error)
+
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Task     Task
+}
+
+type Scheduler struct {
+	jobs   []Job
+	jitter time.Duration
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(jitter time.Duration, jobs ...Job) *Scheduler {
+	return &Scheduler{jobs: jobs, jitter: jitter}
+}
+
+// Start launches one goroutine per job. Each job runs once right away, then
+// waits for its interval (plus a random jitter) before the next run. The
+// wait only begins after the previous run has returned, so runs of the same
+// job never overlap. Jobs with a non positive interval are disabled.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, job := range s.jobs {
+		if job.Interval <= 0 || job.Task == nil {
+			log.Warn().Str("job", job.Name).Msg("scheduler job disabled")
+			continue
+		}
+
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+}
+
+// Stop cancels pending waits and blocks until running tasks have finished.
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	for {
+		s.run(job)
+
+		timer := time.NewTimer(job.Interval + s.nextJitter())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *Scheduler) run(job Job) {
+	start := time.Now()
+	status, httpCode, err := job.Task()
+	if err != nil {
+		log.Error().Err(err).
+			Str("job", job.Name).
+			Int("httpCode", httpCode).
+			Dur("duration", time.Since(start)).
+			Msg("scheduler job failed")
+		return
+	}
+
+	log.Info().
+		Str("job", job.Name).
+		Str("status", status).
+		Int("httpCode", httpCode).
+		Dur("duration", time.Since(start)).
+		Msg("scheduler job done")
+}
+
+func (s *Scheduler) nextJitter() time.Duration {
+	if s.jitter <= 0 {
+		return 0
+	}
+	return rand.N(s.jitter)
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerNoOverlap(t *testing.T) {
+	var (
+		running  int32
+		overlaps int32
+		runs     int32
+	)
+
+	task := func() (string, int, error) {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		defer atomic.AddInt32(&running, -1)
+
+		atomic.AddInt32(&runs, 1)
+		time.Sleep(20 * time.Millisecond)
+		return "stored", http.StatusOK, nil
+	}
+
+	s := New(2*time.Millisecond, Job{Name: "test", Interval: time.Millisecond, Task: task})
+	s.Start(context.Background())
+	time.Sleep(150 * time.Millisecond)
+	s.Stop()
+
+	if atomic.LoadInt32(&runs) == 0 {
+		t.Errorf("Expected task to run at least once")
+	}
+
+	if n := atomic.LoadInt32(&overlaps); n != 0 {
+		t.Errorf("Expected no overlapping runs but got %d", n)
+	}
+}
+
+func TestSchedulerStop(t *testing.T) {
+	var runs int32
+
+	task := func() (string, int, error) {
+		atomic.AddInt32(&runs, 1)
+		return "stored", http.StatusOK, nil
+	}
+
+	s := New(0,
+		Job{Name: "hourly", Interval: time.Hour, Task: task},
+		Job{Name: "disabled", Interval: 0, Task: task},
+	)
+	s.Start(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Stop to return while jobs are waiting")
+	}
+
+	// only the run at start, the disabled job never runs
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("Expected one run at start but got %d", n)
+	}
+}
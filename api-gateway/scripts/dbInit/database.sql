@@ -3,8 +3,10 @@ CREATE EXTENSION postgis;
 create table rideindego_master (
 	fetch_id uuid PRIMARY KEY,
 	type_collection varchar(25) not NULL,
-	last_update TIMESTAMP WITH TIME zone not NULL
+	last_update TIMESTAMP WITH TIME zone not NULL,
+	weather_fetch_id uuid default null
 );
+create unique index idx_master_last_update on rideindego_master(last_update);
 
 
 create table rideindego_features (
@@ -15,6 +17,7 @@ create table rideindego_features (
 	geo_coordinate GEOMETRY(Point,4326),
 	primary key(fetch_id, feat_id)
 );
+create index idx_features_geo_coordinate on rideindego_features using gist(geo_coordinate);
 
 
 create table rideindego_properties(
@@ -55,6 +58,7 @@ create table rideindego_properties(
 	primary key(fetch_id, feat_id, id)
 );
 create index idx_kioskid on rideindego_properties(kiosk_id);
+create index idx_properties_geography on rideindego_properties using gist((coordinates::geography));
 
 create table rideindego_properties_bikes(
 	fetch_id uuid not null,
@@ -97,6 +101,7 @@ create table openweather_master (
 	wind_speed float,
 	primary key(fetch_id)
 );
+create unique index idx_weather_dt_city on openweather_master(dt, id);
 
 create table openweather_weather(
 	fetch_id uuid not null,
@@ -106,4 +111,96 @@ create table openweather_weather(
 	id integer,
 	main varchar,
 	primary key(fetch_id, idx)
-);
\ No newline at end of file
+);
+
+create table raw_payloads(
+	fetch_id uuid not null,
+	source varchar(25) not null,
+	fetched_at TIMESTAMP WITH TIME zone not null default now(),
+	body bytea not null,
+	primary key(fetch_id)
+);
+
+create table ingestion_jobs(
+	job_id uuid not null,
+	status varchar(10) not null,
+	created_at TIMESTAMP WITH TIME zone not null,
+	started_at TIMESTAMP WITH TIME zone default null,
+	finished_at TIMESTAMP WITH TIME zone default null,
+	result jsonb default null,
+	primary key(job_id)
+);
+create index idx_ingestion_jobs_status on ingestion_jobs(status);
+
+create table ingestion_runs(
+	run_id uuid not null,
+	source varchar(25) not null,
+	started_at TIMESTAMP WITH TIME zone not null,
+	finished_at TIMESTAMP WITH TIME zone not null,
+	outcome varchar(10) not null,
+	upstream_status integer not null default 0,
+	fetch_id uuid default null,
+	features integer not null default 0,
+	properties integer not null default 0,
+	bikes integer not null default 0,
+	total_rows integer not null default 0,
+	error varchar not null default '',
+	primary key(run_id)
+);
+create index idx_ingestion_runs_source_started on ingestion_runs(source, started_at);
+
+create table station_hourly(
+	kiosk_id integer not null,
+	bucket TIMESTAMP WITH TIME zone not null,
+	samples integer not null,
+	bikes_min integer not null,
+	bikes_max integer not null,
+	bikes_avg double precision not null,
+	docks_min integer not null,
+	docks_max integer not null,
+	docks_avg double precision not null,
+	pct_empty double precision not null,
+	pct_full double precision not null,
+	primary key(kiosk_id, bucket)
+);
+create index idx_station_hourly_bucket on station_hourly(bucket);
+
+create table station_daily(
+	kiosk_id integer not null,
+	bucket TIMESTAMP WITH TIME zone not null,
+	samples integer not null,
+	bikes_min integer not null,
+	bikes_max integer not null,
+	bikes_avg double precision not null,
+	docks_min integer not null,
+	docks_max integer not null,
+	docks_avg double precision not null,
+	pct_empty double precision not null,
+	pct_full double precision not null,
+	primary key(kiosk_id, bucket)
+);
+create index idx_station_daily_bucket on station_daily(bucket);
+
+create table station_flows(
+	kiosk_id integer not null,
+	bucket TIMESTAMP WITH TIME zone not null,
+	samples integer not null,
+	departures integer not null,
+	arrivals integer not null,
+	primary key(kiosk_id, bucket)
+);
+create index idx_station_flows_bucket on station_flows(bucket);
+
+create table station_anomalies(
+	anomaly_id serial not null,
+	kiosk_id integer not null,
+	name varchar not null default '',
+	kind varchar(15) not null,
+	detail varchar not null default '',
+	opened_at TIMESTAMP WITH TIME zone not null,
+	last_seen_at TIMESTAMP WITH TIME zone not null,
+	closed_at TIMESTAMP WITH TIME zone default null,
+	primary key(anomaly_id)
+);
+create unique index idx_station_anomalies_open on station_anomalies(kiosk_id, kind) where closed_at is null;
+create index idx_station_anomalies_opened on station_anomalies(opened_at);
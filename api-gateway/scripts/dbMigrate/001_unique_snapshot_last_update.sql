@@ -0,0 +1,26 @@
+-- Databases created before snapshots were deduplicated may hold several
+-- copies of the same Indego snapshot. Keep one copy per last_update, then
+-- add the unique index of scripts/dbInit/database.sql. Only the tables
+-- created before the deduplication are touched, the later ones are added
+-- by the next migrations. Safe to run more than once.
+begin;
+
+create temporary table duplicate_snapshots on commit drop as
+select fetch_id
+from (
+	select fetch_id, row_number() over (
+		partition by last_update
+		order by fetch_id
+	) as copy
+	from rideindego_master
+) m
+where copy > 1;
+
+delete from rideindego_properties_bikes where fetch_id in (select fetch_id from duplicate_snapshots);
+delete from rideindego_properties where fetch_id in (select fetch_id from duplicate_snapshots);
+delete from rideindego_features where fetch_id in (select fetch_id from duplicate_snapshots);
+delete from rideindego_master where fetch_id in (select fetch_id from duplicate_snapshots);
+
+create unique index if not exists idx_master_last_update on rideindego_master(last_update);
+
+commit;
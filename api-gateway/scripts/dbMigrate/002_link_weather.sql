@@ -0,0 +1,26 @@
+-- Link the Indego snapshots to their weather and keep one OpenWeather
+-- observation per dt and city, then add the unique index of
+-- scripts/dbInit/database.sql. Safe to run more than once.
+begin;
+
+alter table rideindego_master add column if not exists weather_fetch_id uuid default null;
+
+create temporary table duplicate_weather on commit drop as
+select fetch_id
+from (
+	select fetch_id, row_number() over (
+		partition by dt, id
+		order by fetch_id
+	) as copy
+	from openweather_master
+) w
+where copy > 1;
+
+update rideindego_master set weather_fetch_id = null
+where weather_fetch_id in (select fetch_id from duplicate_weather);
+delete from openweather_weather where fetch_id in (select fetch_id from duplicate_weather);
+delete from openweather_master where fetch_id in (select fetch_id from duplicate_weather);
+
+create unique index if not exists idx_weather_dt_city on openweather_master(dt, id);
+
+commit;
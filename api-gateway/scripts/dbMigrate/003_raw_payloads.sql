@@ -0,0 +1,13 @@
+-- Archive of the upstream body of each fetch, see
+-- scripts/dbInit/database.sql. Safe to run more than once.
+begin;
+
+create table if not exists raw_payloads(
+	fetch_id uuid not null,
+	source varchar(25) not null,
+	fetched_at TIMESTAMP WITH TIME zone not null default now(),
+	body bytea not null,
+	primary key(fetch_id)
+);
+
+commit;
@@ -0,0 +1,16 @@
+-- Asynchronous ingestion jobs, see scripts/dbInit/database.sql. Safe to
+-- run more than once.
+begin;
+
+create table if not exists ingestion_jobs(
+	job_id uuid not null,
+	status varchar(10) not null,
+	created_at TIMESTAMP WITH TIME zone not null,
+	started_at TIMESTAMP WITH TIME zone default null,
+	finished_at TIMESTAMP WITH TIME zone default null,
+	result jsonb default null,
+	primary key(job_id)
+);
+create index if not exists idx_ingestion_jobs_status on ingestion_jobs(status);
+
+commit;
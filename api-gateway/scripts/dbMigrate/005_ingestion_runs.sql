@@ -0,0 +1,22 @@
+-- History of the fetch and store of each source, see
+-- scripts/dbInit/database.sql. Safe to run more than once.
+begin;
+
+create table if not exists ingestion_runs(
+	run_id uuid not null,
+	source varchar(25) not null,
+	started_at TIMESTAMP WITH TIME zone not null,
+	finished_at TIMESTAMP WITH TIME zone not null,
+	outcome varchar(10) not null,
+	upstream_status integer not null default 0,
+	fetch_id uuid default null,
+	features integer not null default 0,
+	properties integer not null default 0,
+	bikes integer not null default 0,
+	total_rows integer not null default 0,
+	error varchar not null default '',
+	primary key(run_id)
+);
+create index if not exists idx_ingestion_runs_source_started on ingestion_runs(source, started_at);
+
+commit;
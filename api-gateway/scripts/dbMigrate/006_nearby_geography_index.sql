@@ -0,0 +1,3 @@
+-- Index the station coordinates as geography, the cast used by the nearby
+-- stations search. Safe to run more than once.
+create index if not exists idx_properties_geography on rideindego_properties using gist((coordinates::geography));
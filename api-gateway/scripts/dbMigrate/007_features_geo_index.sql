@@ -0,0 +1,3 @@
+-- Index the station geometries searched by bounding box and polygon. Safe
+-- to run more than once.
+create index if not exists idx_features_geo_coordinate on rideindego_features using gist(geo_coordinate);
@@ -0,0 +1,52 @@
+-- Hourly and daily rollups of the stations, see scripts/dbInit/database.sql.
+-- The days were aligned to UTC before, they are rebuilt from the hourly rows
+-- to start at midnight in America/New_York. Safe to run more than once.
+begin;
+
+create table if not exists station_hourly(
+	kiosk_id integer not null,
+	bucket TIMESTAMP WITH TIME zone not null,
+	samples integer not null,
+	bikes_min integer not null,
+	bikes_max integer not null,
+	bikes_avg double precision not null,
+	docks_min integer not null,
+	docks_max integer not null,
+	docks_avg double precision not null,
+	pct_empty double precision not null,
+	pct_full double precision not null,
+	primary key(kiosk_id, bucket)
+);
+create index if not exists idx_station_hourly_bucket on station_hourly(bucket);
+
+create table if not exists station_daily(
+	kiosk_id integer not null,
+	bucket TIMESTAMP WITH TIME zone not null,
+	samples integer not null,
+	bikes_min integer not null,
+	bikes_max integer not null,
+	bikes_avg double precision not null,
+	docks_min integer not null,
+	docks_max integer not null,
+	docks_avg double precision not null,
+	pct_empty double precision not null,
+	pct_full double precision not null,
+	primary key(kiosk_id, bucket)
+);
+create index if not exists idx_station_daily_bucket on station_daily(bucket);
+
+delete from station_daily;
+insert into station_daily
+	(kiosk_id, bucket, samples, bikes_min, bikes_max, bikes_avg,
+	docks_min, docks_max, docks_avg, pct_empty, pct_full)
+	select kiosk_id,
+	date_trunc('day', bucket at time zone 'America/New_York') at time zone 'America/New_York',
+	sum(samples),
+	min(bikes_min), max(bikes_max), sum(bikes_avg * samples) / sum(samples),
+	min(docks_min), max(docks_max), sum(docks_avg * samples) / sum(samples),
+	sum(pct_empty * samples) / sum(samples),
+	sum(pct_full * samples) / sum(samples)
+	from station_hourly
+	group by 1, 2;
+
+commit;
@@ -0,0 +1,15 @@
+-- Flows of the stations by hour, see scripts/dbInit/database.sql. Safe to
+-- run more than once.
+begin;
+
+create table if not exists station_flows(
+	kiosk_id integer not null,
+	bucket TIMESTAMP WITH TIME zone not null,
+	samples integer not null,
+	departures integer not null,
+	arrivals integer not null,
+	primary key(kiosk_id, bucket)
+);
+create index if not exists idx_station_flows_bucket on station_flows(bucket);
+
+commit;
@@ -0,0 +1,19 @@
+-- Anomalies detected on the stations, see scripts/dbInit/database.sql.
+-- Safe to run more than once.
+begin;
+
+create table if not exists station_anomalies(
+	anomaly_id serial not null,
+	kiosk_id integer not null,
+	name varchar not null default '',
+	kind varchar(15) not null,
+	detail varchar not null default '',
+	opened_at TIMESTAMP WITH TIME zone not null,
+	last_seen_at TIMESTAMP WITH TIME zone not null,
+	closed_at TIMESTAMP WITH TIME zone default null,
+	primary key(anomaly_id)
+);
+create unique index if not exists idx_station_anomalies_open on station_anomalies(kiosk_id, kind) where closed_at is null;
+create index if not exists idx_station_anomalies_opened on station_anomalies(opened_at);
+
+commit;
@@ -37,3 +37,13 @@ export SERVER_TIMEOUT
 OPENWEATHER_APIKEY=e4d25c020947523c7c18b8e4af1ce00e
 
 export OPENWEATHER_APIKEY
+
+
+# ingestion scheduler, in seconds
+SCHEDULER_INDEGO_INTERVAL=3600
+SCHEDULER_WEATHER_INTERVAL=3600
+SCHEDULER_JITTER=60
+
+export SCHEDULER_INDEGO_INTERVAL
+export SCHEDULER_WEATHER_INTERVAL
+export SCHEDULER_JITTER
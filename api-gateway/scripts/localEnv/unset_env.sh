@@ -13,3 +13,7 @@ unset DB_DSN
 
 unset SERVER_PORT 
 unset SERVER_TIMEOUT
+
+unset SCHEDULER_INDEGO_INTERVAL
+unset SCHEDULER_WEATHER_INTERVAL
+unset SCHEDULER_JITTER